@@ -10,6 +10,8 @@ import (
 	"strings"
 	"time"
 
+	"recon-service/internal/fx"
+	"recon-service/internal/models"
 	"recon-service/internal/parser"
 	"recon-service/internal/reconcile"
 	"recon-service/internal/util"
@@ -19,18 +21,96 @@ import (
 func main() {
 	var systemCSV string
 	var bankCSVPaths multiString
+	var bankDirs multiString
 	var startDateStr string
 	var endDateStr string
 	var outputJSON bool
+	var matcherNames string
+	var heuristic bool
+	var heuristicDays int
+	var groups bool
+	var groupDays int
+	var groupMaxSize int
+	var toleranceSpec string
+	var bankTolerances multiString
+	var sysDupPolicy string
+	var bankLagDays int
+	var bankLeadDays int
+	var maxSettlementDays int
+	var tzName string
+	var cutoffStr string
+	var bankCutoffs multiString
+	var currency string
+	var bankCurrencies multiString
+	var fxPath string
+	var reportingCurrency string
+	var fxToleranceSpec string
+	var roundingName string
+	var systemRoundingName string
+	var bankRoundings multiString
+	var strict bool
+	var numberFormatName string
+	var systemNumberFormatName string
+	var bankNumberFormats multiString
+	var bankLayouts multiString
+	var profilesPath string
+	var systemProfileName string
+	var bankProfileNames multiString
+	var bankDateLayouts multiString
+	var lenient bool
+	var maxRejectRate float64
+	var rejectsOut string
+	var encodingName string
+	var delimiterName string
+	var reportAttributes string
 
-	flag.StringVar(&systemCSV, "system", "", "Path to system transactions CSV")
-	flag.Var(&bankCSVPaths, "bank", "Path to bank statement CSV (can be specified multiple times)")
+	flag.StringVar(&systemCSV, "system", "", "Path to system transactions CSV (- reads stdin; .gz, .bz2 and .zip are decompressed)")
+	flag.Var(&bankCSVPaths, "bank", "Bank statement CSV or camt.053 XML path or glob, optionally named as bank=path, e.g. bca=/data/bca/*.csv (can be specified multiple times; - reads stdin; .gz, .bz2 and .zip are decompressed)")
+	flag.Var(&bankDirs, "bank-dir", "Directory of bank statements: each subdirectory is one bank named after it, or bank=dir reads every file as that bank (can be specified multiple times)")
 	flag.StringVar(&startDateStr, "start", "", "Start date (YYYY-MM-DD)")
 	flag.StringVar(&endDateStr, "end", "", "End date (YYYY-MM-DD)")
 	flag.BoolVar(&outputJSON, "json", true, "Output JSON summary")
+	flag.StringVar(&matcherNames, "matchers", "exact", "Ordered matcher chain (exact, normalized, reference, amountdate)")
+	flag.BoolVar(&heuristic, "heuristic", false, "Pair rows left unmatched by ID using signed amount and date proximity (appends amountdate to -matchers)")
+	flag.IntVar(&heuristicDays, "heuristic-days", 2, "Max day distance for the amountdate matcher")
+	flag.BoolVar(&groups, "groups", false, "Match split/batched settlements (many-to-one and one-to-many) after the matcher chain")
+	flag.IntVar(&groupDays, "group-days", 2, "Max day distance between group members and their counterpart")
+	flag.IntVar(&groupMaxSize, "group-max-size", 5, "Max number of rows in one group")
+	flag.StringVar(&toleranceSpec, "tolerance", "", "Default amount tolerance: minor units and/or percent, e.g. 100 or 0.5% or 100,0.5%")
+	flag.Var(&bankTolerances, "bank-tolerance", "Per-bank amount tolerance as bank=spec, e.g. bank_bca=100,0.5% (can be specified multiple times)")
+	flag.StringVar(&sysDupPolicy, "system-duplicates", "last", "Policy for repeated system trxIDs: first, last, sum or reject")
+	flag.IntVar(&bankLagDays, "bank-lag-days", 0, "Days a bank may settle after the system booking: bank rows up to N days after -end and system rows up to N days before -start are used as counterparts only")
+	flag.IntVar(&bankLeadDays, "bank-lead-days", 0, "Days a bank may post before the system booking: bank rows up to N days before -start and system rows up to N days after -end are used as counterparts only")
+	flag.IntVar(&maxSettlementDays, "max-settlement-days", -1, "Report ID-matched pairs whose system and bank dates are more than N days apart (0 requires the same day, -1 disables)")
+	flag.StringVar(&tzName, "tz", "", "Business timezone (IANA name, e.g. Asia/Jakarta); system times without an offset are read in it")
+	flag.StringVar(&cutoffStr, "cutoff", "", "Default bank cut-off time (HH:MM); system times at or after it count for the next business date")
+	flag.Var(&bankCutoffs, "bank-cutoff", "Per-bank cut-off time as bank=HH:MM (can be specified multiple times)")
+	flag.StringVar(&currency, "currency", models.DefaultCurrency, "Currency (ISO 4217) for rows without a currency column")
+	flag.Var(&bankCurrencies, "bank-currency", "Per-bank currency for rows without a currency column as bank=CODE (can be specified multiple times)")
+	flag.StringVar(&fxPath, "fx", "", "Dated FX rate CSV (date,from,to,rate) used to compare pairs booked in different currencies")
+	flag.StringVar(&reportingCurrency, "reporting-currency", models.DefaultCurrency, "Currency cross-currency pairs are converted into before comparing")
+	flag.StringVar(&fxToleranceSpec, "fx-tolerance", "", "Tolerance for converted differences reported as FX differences, same format as -tolerance")
+	flag.StringVar(&roundingName, "rounding", "truncate", "Rounding of amounts with more fractional digits than the currency allows: truncate, half-up, half-even or error")
+	flag.StringVar(&systemRoundingName, "system-rounding", "", "Rounding mode for the system CSV (defaults to -rounding)")
+	flag.Var(&bankRoundings, "bank-rounding", "Per-bank rounding mode as bank=mode (can be specified multiple times)")
+	flag.BoolVar(&strict, "strict", false, "Fail with the row number whenever an amount would lose precision (rounding mode error for every source)")
+	flag.StringVar(&numberFormatName, "number-format", "auto", "Amount format: dot (1,234.56), comma (1.234,56) or auto (ambiguous values such as 1.234 are rejected)")
+	flag.StringVar(&systemNumberFormatName, "system-number-format", "", "Amount format for the system CSV (defaults to -number-format)")
+	flag.Var(&bankNumberFormats, "bank-number-format", "Per-bank amount format as bank=format (can be specified multiple times)")
+	flag.Var(&bankLayouts, "bank-layout", "Per-bank amount layout as bank=layout: signed (amount), debit-credit (debit,credit) or indicator (amount,indicator) (can be specified multiple times)")
+	flag.StringVar(&profilesPath, "profiles", "", "JSON file of named column mapping profiles")
+	flag.StringVar(&systemProfileName, "system-profile", "", "Profile (from -profiles) for the system CSV")
+	flag.Var(&bankProfileNames, "bank-profile", "Per-bank profile (from -profiles) as bank=profile (can be specified multiple times)")
+	flag.Var(&bankDateLayouts, "bank-date-layout", "Per-bank date layout (Go reference time) as bank=layout, e.g. bank_bni=02/01/2006; repeat to try several layouts in order")
+	flag.BoolVar(&lenient, "lenient", false, "Skip invalid rows instead of failing; skipped rows are listed under rejectedRows")
+	flag.Float64Var(&maxRejectRate, "max-reject-rate", 5, "With -lenient, fail when more than this percent of a file's rows is rejected (0 means no limit)")
+	flag.StringVar(&rejectsOut, "rejects-out", "", "Write rejected rows to this CSV file (file,line,reason,record)")
+	flag.StringVar(&encodingName, "encoding", "auto", "Input encoding: auto (BOM/content detection), utf-8, utf-16le, utf-16be or windows-1252")
+	flag.StringVar(&delimiterName, "delimiter", "auto", "Field delimiter: auto (detect), \",\", \";\", \"|\" or tab")
+	flag.StringVar(&reportAttributes, "report-attributes", "", "Comma-separated extra columns (e.g. description,channel) shown next to unmatched and discrepant items; * shows all")
 	flag.Parse()
 
-	if systemCSV == "" || len(bankCSVPaths) == 0 || startDateStr == "" || endDateStr == "" {
+	if systemCSV == "" || len(bankCSVPaths)+len(bankDirs) == 0 || startDateStr == "" || endDateStr == "" {
 		flag.Usage()
 		os.Exit(2)
 	}
@@ -46,27 +126,316 @@ func main() {
 	if endDate.Before(startDate) {
 		log.Fatalf("end date must be on/after start date")
 	}
+	if bankLagDays < 0 || bankLeadDays < 0 {
+		log.Fatalf("-bank-lag-days and -bank-lead-days must not be negative")
+	}
+
+	matchers, err := reconcile.MatchersByName(strings.Split(matcherNames, ","), heuristicDays)
+	if err != nil {
+		log.Fatalf("invalid -matchers: %v", err)
+	}
+
+	tolerance, err := reconcile.ParseTolerance(toleranceSpec)
+	if err != nil {
+		log.Fatalf("invalid -tolerance: %v", err)
+	}
+	bankTolerance := map[string]reconcile.Tolerance{}
+	for _, kv := range bankTolerances {
+		bank, spec, err := splitKeyValue(kv)
+		if err != nil {
+			log.Fatalf("invalid -bank-tolerance: %v", err)
+		}
+		t, err := reconcile.ParseTolerance(spec)
+		if err != nil {
+			log.Fatalf("invalid -bank-tolerance for %s: %v", bank, err)
+		}
+		bankTolerance[bank] = t
+	}
+
+	dupPolicy, err := reconcile.ParseDuplicatePolicy(sysDupPolicy)
+	if err != nil {
+		log.Fatalf("invalid -system-duplicates: %v", err)
+	}
+
+	cal := util.Calendar{BankCutoff: map[string]time.Duration{}}
+	if tzName != "" {
+		cal.Location, err = time.LoadLocation(tzName)
+		if err != nil {
+			log.Fatalf("invalid -tz: %v", err)
+		}
+	}
+	if cutoffStr != "" {
+		cal.Cutoff, err = util.ParseTimeOfDay(cutoffStr)
+		if err != nil {
+			log.Fatalf("invalid -cutoff: %v", err)
+		}
+	}
+	for _, kv := range bankCutoffs {
+		bank, v, err := splitKeyValue(kv)
+		if err != nil {
+			log.Fatalf("invalid -bank-cutoff: %v", err)
+		}
+		cal.BankCutoff[bank], err = util.ParseTimeOfDay(v)
+		if err != nil {
+			log.Fatalf("invalid -bank-cutoff for %s: %v", bank, err)
+		}
+	}
+
+	if _, err := models.CurrencyExponent(currency); err != nil {
+		log.Fatalf("invalid -currency: %v", err)
+	}
+	bankCurrency := map[string]string{}
+	for _, kv := range bankCurrencies {
+		bank, code, err := splitKeyValue(kv)
+		if err != nil {
+			log.Fatalf("invalid -bank-currency: %v", err)
+		}
+		if _, err := models.CurrencyExponent(code); err != nil {
+			log.Fatalf("invalid -bank-currency for %s: %v", bank, err)
+		}
+		bankCurrency[bank] = code
+	}
+
+	var fxTable *fx.Table
+	if fxPath != "" {
+		fxTable, err = fx.LoadCSV(fxPath)
+		if err != nil {
+			log.Fatalf("read fx csv failed: %v", err)
+		}
+	}
+	if _, err := models.CurrencyExponent(reportingCurrency); err != nil {
+		log.Fatalf("invalid -reporting-currency: %v", err)
+	}
+	fxTolerance, err := reconcile.ParseTolerance(fxToleranceSpec)
+	if err != nil {
+		log.Fatalf("invalid -fx-tolerance: %v", err)
+	}
+
+	rounding, err := parser.ParseRoundingMode(roundingName)
+	if err != nil {
+		log.Fatalf("invalid -rounding: %v", err)
+	}
+	systemRounding, err := parser.ParseRoundingMode(systemRoundingName)
+	if err != nil {
+		log.Fatalf("invalid -system-rounding: %v", err)
+	}
+	bankRounding := map[string]parser.RoundingMode{}
+	for _, kv := range bankRoundings {
+		bank, name, err := splitKeyValue(kv)
+		if err != nil {
+			log.Fatalf("invalid -bank-rounding: %v", err)
+		}
+		if bankRounding[bank], err = parser.ParseRoundingMode(name); err != nil {
+			log.Fatalf("invalid -bank-rounding for %s: %v", bank, err)
+		}
+	}
+
+	numberFormat, err := parser.ParseNumberFormat(numberFormatName)
+	if err != nil {
+		log.Fatalf("invalid -number-format: %v", err)
+	}
+	systemNumberFormat, err := parser.ParseNumberFormat(systemNumberFormatName)
+	if err != nil {
+		log.Fatalf("invalid -system-number-format: %v", err)
+	}
+	bankNumberFormat := map[string]parser.NumberFormat{}
+	for _, kv := range bankNumberFormats {
+		bank, name, err := splitKeyValue(kv)
+		if err != nil {
+			log.Fatalf("invalid -bank-number-format: %v", err)
+		}
+		if bankNumberFormat[bank], err = parser.ParseNumberFormat(name); err != nil {
+			log.Fatalf("invalid -bank-number-format for %s: %v", bank, err)
+		}
+	}
+	bankLayout := map[string]parser.AmountLayout{}
+	for _, kv := range bankLayouts {
+		bank, name, err := splitKeyValue(kv)
+		if err != nil {
+			log.Fatalf("invalid -bank-layout: %v", err)
+		}
+		if bankLayout[bank], err = parser.ParseAmountLayout(name); err != nil {
+			log.Fatalf("invalid -bank-layout for %s: %v", bank, err)
+		}
+	}
+	bankDateLayout := map[string][]string{}
+	for _, kv := range bankDateLayouts {
+		bank, layout, err := splitKeyValue(kv)
+		if err != nil {
+			log.Fatalf("invalid -bank-date-layout: %v", err)
+		}
+		bankDateLayout[bank] = append(bankDateLayout[bank], layout)
+	}
+
+	profiles := map[string]parser.Profile{}
+	if profilesPath != "" {
+		if profiles, err = parser.LoadProfiles(profilesPath); err != nil {
+			log.Fatalf("read profiles failed: %v", err)
+		}
+	}
+	profileNamed := func(flagName, name string) parser.Profile {
+		p, ok := profiles[name]
+		if !ok {
+			log.Fatalf("invalid %s: unknown profile %q", flagName, name)
+		}
+		return p
+	}
+	bankProfile := map[string]parser.Profile{}
+	for _, kv := range bankProfileNames {
+		bank, name, err := splitKeyValue(kv)
+		if err != nil {
+			log.Fatalf("invalid -bank-profile: %v", err)
+		}
+		bankProfile[bank] = profileNamed("-bank-profile", name)
+	}
 
-	sysTxns, err := parser.ReadSystemTransactions(systemCSV)
+	encoding, err := parser.ParseEncoding(encodingName)
 	if err != nil {
-		log.Fatalf("read system csv failed: %v", err)
+		log.Fatalf("invalid -encoding: %v", err)
+	}
+	delimiter, err := parser.ParseDelimiter(delimiterName)
+	if err != nil {
+		log.Fatalf("invalid -delimiter: %v", err)
+	}
+
+	// Precedence: global flags, then the source profile, then per-source flags; -strict wins
+	sysOpts := parser.Options{Location: cal.Location, Currency: currency, NumberFormat: numberFormat, Rounding: rounding, Encoding: encoding, Delimiter: delimiter}
+	if systemProfileName != "" {
+		sysOpts = profileNamed("-system-profile", systemProfileName).Apply(sysOpts)
 	}
+	if systemNumberFormatName != "" {
+		sysOpts.NumberFormat = systemNumberFormat
+	}
+	if systemRoundingName != "" {
+		sysOpts.Rounding = systemRounding
+	}
+	if strict {
+		sysOpts.Rounding = parser.RoundError
+	}
+	sysOpts.Lenient, sysOpts.MaxRejectPercent = lenient, maxRejectRate
+	var sysTxns []models.SystemTransaction
+	var rejects []parser.Reject
+	walkInputs(systemCSV, func(in parser.Input) error {
+		sysFile, err := parser.ReadSystemFileFrom(in, in.Name, sysOpts)
+		if err != nil {
+			log.Fatalf("read system csv failed (%s): %v", in.Name, err)
+		}
+		sysTxns = append(sysTxns, sysFile.Rows...)
+		rejects = append(rejects, sysFile.Rejects...)
+		return nil
+	})
 
 	var bankAll []*parser.BankFile
-	for _, p := range bankCSVPaths {
-		name := bankNameFromPath(p)
-		records, err := parser.ReadBankStatements(p, name)
+	// Files given under one bank name are merged; a file repeating earlier content is skipped
+	var bankSources []parser.BankSource
+	for _, spec := range bankCSVPaths {
+		srcs, err := parser.ExpandBankSpec(spec)
+		if err != nil {
+			log.Fatalf("invalid -bank %q: %v", spec, err)
+		}
+		bankSources = append(bankSources, srcs...)
+	}
+	for _, spec := range bankDirs {
+		srcs, err := parser.ExpandBankDir(spec)
 		if err != nil {
-			log.Fatalf("read bank csv failed (%s): %v", p, err)
+			log.Fatalf("read -bank-dir failed: %v", err)
+		}
+		bankSources = append(bankSources, srcs...)
+	}
+	var notes []string
+	var dups parser.DuplicateInputs
+	for _, src := range bankSources {
+		walkInputs(src.Path, func(in parser.Input) error {
+			name := src.Name
+			if name == "" {
+				name = bankNameFromPath(in.Name)
+			}
+			opts := parser.Options{Currency: currency, NumberFormat: numberFormat, Rounding: rounding, Encoding: encoding, Delimiter: delimiter}
+			if prof, ok := bankProfile[name]; ok {
+				opts = prof.Apply(opts)
+			}
+			if c, ok := bankCurrency[name]; ok {
+				opts.Currency = c
+			}
+			if m, ok := bankRounding[name]; ok {
+				opts.Rounding = m
+			}
+			if f, ok := bankNumberFormat[name]; ok {
+				opts.NumberFormat = f
+			}
+			if l, ok := bankLayout[name]; ok {
+				opts.AmountLayout = l
+			}
+			if l, ok := bankDateLayout[name]; ok {
+				opts.DateLayouts = l
+			}
+			if strict {
+				opts.Rounding = parser.RoundError
+			}
+			opts.Lenient, opts.MaxRejectPercent = lenient, maxRejectRate
+			hr := parser.NewHashReader(in)
+			records, err := parser.ReadBankFileFrom(hr, in.Name, name, opts)
+			if err != nil {
+				log.Fatalf("read bank statement failed (%s): %v", in.Name, err)
+			}
+			hash, err := hr.Sum()
+			if err != nil {
+				log.Fatalf("read bank statement failed (%s): %v", in.Name, err)
+			}
+			if d, ok := dups.Check(in.Name, hash); ok {
+				notes = append(notes, fmt.Sprintf("duplicate bank file skipped: %s has the same content as %s (sha256 %s)", d.Name, d.SameAs, d.Hash[:16]))
+				return nil
+			}
+			bankAll = append(bankAll, records)
+			rejects = append(rejects, records.Rejects...)
+			return nil
+		})
+	}
+	if rejectsOut != "" {
+		if err := writeRejects(rejectsOut, rejects); err != nil {
+			log.Fatalf("write rejects failed: %v", err)
+		}
+	}
+
+	// Business dates first, then filter by date range keeping the settlement buffer as counterparts
+	sysTxns = util.ApplyBusinessDates(sysTxns, cal)
+	buffer := util.Buffer{LagDays: bankLagDays, LeadDays: bankLeadDays}
+	var bankNameList []string
+	seenBank := map[string]bool{}
+	for _, bf := range bankAll {
+		if !seenBank[bf.BankName] {
+			seenBank[bf.BankName] = true
+			bankNameList = append(bankNameList, bf.BankName)
 		}
-		bankAll = append(bankAll, records)
 	}
+	sysFiltered := util.FilterSystemByDateWithBuffer(sysTxns, startDate, endDate, buffer, bankNameList...)
+	bankFiltered := util.FilterBanksByDateWithBuffer(bankAll, startDate, endDate, buffer)
 
-	// Normalize and filter by date range
-	sysFiltered := util.FilterSystemByDate(sysTxns, startDate, endDate)
-	bankFiltered := util.FilterBanksByDate(bankAll, startDate, endDate)
+	var maxDateGap *int
+	if maxSettlementDays >= 0 {
+		maxDateGap = &maxSettlementDays
+	}
 
-	res := reconcile.Reconcile(sysFiltered, bankFiltered)
+	res := reconcile.ReconcileWithOptions(sysFiltered, bankFiltered, reconcile.Options{
+		Matchers:  matchers,
+		Heuristic: heuristic,
+		DayWindow: heuristicDays,
+		Group: reconcile.GroupOptions{
+			Enabled:      groups,
+			DayWindow:    groupDays,
+			MaxGroupSize: groupMaxSize,
+		},
+		Tolerance:         tolerance,
+		BankTolerance:     bankTolerance,
+		SystemDuplicates:  dupPolicy,
+		MaxDateGapDays:    maxDateGap,
+		FX:                fxTable,
+		ReportingCurrency: reportingCurrency,
+		FXTolerance:       fxTolerance,
+		Rejects:           rejects,
+		ReportAttributes:  splitList(reportAttributes),
+		Notes:             notes,
+	})
 
 	if outputJSON {
 		enc := json.NewEncoder(os.Stdout)
@@ -90,8 +459,53 @@ func (m *multiString) Set(value string) error {
 	return nil
 }
 
+// splitList splits a comma-separated flag value, dropping empty items
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// splitKeyValue splits "key=value" flag values
+func splitKeyValue(s string) (string, string, error) {
+	k, v, ok := strings.Cut(s, "=")
+	k = strings.TrimSpace(k)
+	if !ok || k == "" {
+		return "", "", fmt.Errorf("expected key=value, got %q", s)
+	}
+	return k, strings.TrimSpace(v), nil
+}
+
+// stdinRead guards against giving "-" to more than one input
+var stdinRead bool
+
+// walkInputs streams each document of a path or "-" to fn, expanding compressed files and zip archives
+func walkInputs(path string, fn func(parser.Input) error) {
+	if path == parser.StdinName {
+		if stdinRead {
+			log.Fatalf("stdin (-) can only be used for one input")
+		}
+		stdinRead = true
+	}
+	if err := parser.WalkInputs(path, os.Stdin, fn); err != nil {
+		log.Fatalf("read input failed (%s): %v", path, err)
+	}
+}
+
+// bankNameFromPath derives the bank name from a file or archive entry name,
+// e.g. "bca.csv.gz" and "daily.zip!/bca.csv" both give "bca"
 func bankNameFromPath(path string) string {
+	if path == parser.StdinName {
+		return "stdin"
+	}
 	base := filepath.Base(path)
+	for _, ext := range []string{".gz", ".bz2"} {
+		base = strings.TrimSuffix(base, ext)
+	}
 	ext := filepath.Ext(base)
 	base = strings.TrimSuffix(base, ext)
 	if base == "" {
@@ -100,4 +514,14 @@ func bankNameFromPath(path string) string {
 	return base
 }
 
-
+func writeRejects(path string, rejects []parser.Reject) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := parser.WriteRejectsCSV(f, rejects); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
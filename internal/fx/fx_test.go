@@ -0,0 +1,71 @@
+package fx_test
+
+import (
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"recon-service/internal/fx"
+)
+
+// Note: Comments in English per instruction
+
+func TestLoadCSV_ConvertAcrossExponents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.csv")
+	content := "date,from,to,rate\n" +
+		"2024-09-01,USD,IDR,15750\n" +
+		"2024-09-03,USD,IDR,15800\n" +
+		"2024-09-01,JPY,IDR,108.5\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	table, err := fx.LoadCSV(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+
+	cases := []struct {
+		amount   int64
+		from, to string
+		date     string
+		want     int64
+	}{
+		{1000, "USD", "IDR", "2024-09-02", 15750000}, // latest rate on or before the date
+		{1000, "USD", "IDR", "2024-09-03", 15800000},
+		{-1000, "USD", "IDR", "2024-09-03", -15800000},
+		{15750000, "IDR", "USD", "2024-09-02", 1000}, // inverse pair
+		{7, "JPY", "IDR", "2024-09-01", 75950},       // exponent 0 -> 2
+		{500, "IDR", "IDR", "2024-01-01", 500},
+	}
+	for _, c := range cases {
+		got, err := table.Convert(c.amount, c.from, c.to, day(c.date))
+		if err != nil {
+			t.Fatalf("convert %d %s->%s: %v", c.amount, c.from, c.to, err)
+		}
+		if got != c.want {
+			t.Fatalf("convert %d %s->%s on %s got=%d want=%d", c.amount, c.from, c.to, c.date, got, c.want)
+		}
+	}
+	if _, err := table.Convert(1000, "USD", "IDR", day("2024-08-31")); err == nil {
+		t.Fatalf("expected error before the first rate")
+	}
+}
+
+func TestConvert_Overflow(t *testing.T) {
+	date := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	table := fx.NewTable()
+	table.Add(date, "USD", "IDR", big.NewRat(16000, 1))
+	if _, err := table.Convert(math.MaxInt64/1000, "USD", "IDR", date); err == nil {
+		t.Fatalf("expected overflow error")
+	}
+	if _, err := table.Convert(math.MinInt64/1000, "USD", "IDR", date); err == nil {
+		t.Fatalf("expected overflow error for negative amounts")
+	}
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Note: Comments in English per instruction
+
+// DefaultCurrency applies to rows without a currency
+const DefaultCurrency = "IDR"
+
+// currencyExponents holds ISO 4217 minor-unit exponents
+var currencyExponents = map[string]int{
+	"AUD": 2, "BHD": 3, "CHF": 2, "CLP": 0, "CNY": 2, "EUR": 2, "GBP": 2,
+	"HKD": 2, "IDR": 2, "INR": 2, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0,
+	"KWD": 3, "MYR": 2, "NZD": 2, "OMR": 3, "PHP": 2, "SAR": 2, "SGD": 2,
+	"THB": 2, "TND": 3, "TWD": 2, "USD": 2, "VND": 0,
+}
+
+// NormalizeCurrency upper-cases a code; empty means DefaultCurrency
+func NormalizeCurrency(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return DefaultCurrency
+	}
+	return code
+}
+
+// CurrencyExponent returns the number of minor-unit digits for an ISO 4217 code
+func CurrencyExponent(code string) (int, error) {
+	e, ok := currencyExponents[NormalizeCurrency(code)]
+	if !ok {
+		return 0, fmt.Errorf("unknown currency: %s", code)
+	}
+	return e, nil
+}
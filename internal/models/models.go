@@ -17,17 +17,61 @@ const (
 // SystemTransaction represents internal system transaction
 type SystemTransaction struct {
 	TrxID           string
-	AmountMinor     int64 // amount in minor unit (e.g., cents)
+	AmountMinor     int64  // amount in minor unit of Currency (e.g., cents)
+	Currency        string // ISO 4217 code
 	Type            TransactionType
 	TransactionTime time.Time
+	// OutOfPeriod marks a row from the settlement buffer outside the requested date range;
+	// it may serve as a counterpart but is not counted or reported as unmatched
+	OutOfPeriod bool
+	// BusinessDate is the bank business date (midnight UTC) after timezone and cut-off;
+	// zero means the calendar date of TransactionTime
+	BusinessDate time.Time
+	// BankBusinessDates overrides BusinessDate for banks with their own cut-off
+	BankBusinessDates map[string]time.Time
+	Source            Source
+	// Attributes holds extra source columns (header -> value), e.g. description or channel
+	Attributes map[string]string
 }
 
 // BankStatement represents a single bank row
 type BankStatement struct {
 	UniqueIdentifier string
-	AmountMinor      int64 // signed: negative for debit, positive for credit
+	AmountMinor      int64     // signed: negative for debit, positive for credit
+	Currency         string    // ISO 4217 code
 	Date             time.Time // date only (normalized to midnight)
 	BankName         string
+	OutOfPeriod      bool // see SystemTransaction.OutOfPeriod
+	Source           Source
+	Attributes       map[string]string // see SystemTransaction.Attributes
+}
+
+// Source locates the raw record a row was parsed from
+type Source struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	// RecordHash identifies the raw fields (see parser), e.g. to spot edited or repeated records
+	RecordHash string `json:"recordHash"`
+}
+
+// String formats the source as "path:line #hash"; empty when unknown
+func (s Source) String() string {
+	if s.Path == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d #%s", s.Path, s.Line, s.RecordHash)
+}
+
+// DateFor returns the business date used when comparing with rows of the given bank
+func (s SystemTransaction) DateFor(bank string) time.Time {
+	if d, ok := s.BankBusinessDates[bank]; ok {
+		return d
+	}
+	if !s.BusinessDate.IsZero() {
+		return s.BusinessDate
+	}
+	t := s.TransactionTime
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
 }
 
 func (t TransactionType) SignedAmount(minor int64) (int64, error) {
@@ -46,5 +90,3 @@ func (t TransactionType) SignedAmount(minor int64) (int64, error) {
 		return 0, fmt.Errorf("unknown transaction type: %s", t)
 	}
 }
-
-
@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+// Note: Comments in English per instruction
+
+func TestReadCamt053(t *testing.T) {
+	bf, err := ReadCamt053(filepath.Join("..", "..", "testdata", "camt", "bank_mandiri_camt053.xml"), "mandiri", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bf.Meta.AccountNumber != "ID12BMRI0001234567890" || bf.Meta.Currency != "IDR" {
+		t.Fatalf("unexpected account meta: %+v", bf.Meta)
+	}
+	if bf.Meta.OpeningBalanceMinor == nil || *bf.Meta.OpeningBalanceMinor != 100000000 || bf.Meta.ClosingBalanceMinor == nil || *bf.Meta.ClosingBalanceMinor != 90000000 {
+		t.Fatalf("unexpected balances: %+v", bf.Meta)
+	}
+	if !bf.Meta.PeriodStart.Equal(time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)) || !bf.Meta.PeriodEnd.Equal(time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected period: %+v", bf.Meta)
+	}
+
+	// the pending entry is skipped; the batch entry is split per transaction
+	want := []struct {
+		id     string
+		amount int64
+		date   string
+	}{
+		{"TX-003", 5000000, "2024-01-06"},
+		{"TX-004", -12500000, "2024-01-07"},
+		{"MDR240107003", -2500000, "2024-01-07"},
+	}
+	if len(bf.Rows) != len(want) {
+		t.Fatalf("got %d rows: %+v", len(bf.Rows), bf.Rows)
+	}
+	for i, w := range want {
+		r := bf.Rows[i]
+		if r.UniqueIdentifier != w.id || r.AmountMinor != w.amount || r.Date.Format("2006-01-02") != w.date || r.Currency != "IDR" || r.BankName != "mandiri" {
+			t.Fatalf("row %d got=%+v want=%+v", i, r, w)
+		}
+	}
+	a := bf.Rows[1].Attributes
+	if a[CamtEntryReference] != "0002" || a[CamtServicerRef] != "MDR240107002" || a[CamtValueDate] != "2024-01-08" || a[CamtAccount] != "ID12BMRI0001234567890" {
+		t.Fatalf("unexpected attributes: %v", a)
+	}
+	if bf.Rows[0].Attributes[CamtRemittance] != "Invoice TX-003" || bf.Rows[0].Source.Line == 0 {
+		t.Fatalf("unexpected first row: %+v", bf.Rows[0])
+	}
+	if _, ok := bf.Rows[2].Attributes[CamtEndToEndID]; ok {
+		t.Fatalf("NOTPROVIDED must not be kept: %v", bf.Rows[2].Attributes)
+	}
+}
+
+func TestReadCamt053_LenientAndDetection(t *testing.T) {
+	doc := `<?xml version="1.0"?>
+<Document><BkToCstmrStmt><Stmt>
+<Acct><Id><Othr><Id>1234567</Id></Othr></Id></Acct>
+<Ntry><NtryRef>A1</NtryRef><Amt Ccy="USD">12.50</Amt><CdtDbtInd>DBIT</CdtDbtInd><BookgDt><DtTm>2024-01-02T23:30:00+07:00</DtTm></BookgDt></Ntry>
+<Ntry><NtryRef>A2</NtryRef><Amt Ccy="USD">1,00</Amt><CdtDbtInd>CRDT</CdtDbtInd><BookgDt><Dt>2024-01-02</Dt></BookgDt></Ntry>
+</Stmt></BkToCstmrStmt></Document>`
+
+	if _, err := ReadBankFileFrom(strings.NewReader(doc), "s.xml", "b", Options{}); err == nil || !strings.Contains(err.Error(), "row 5") {
+		t.Fatalf("expected row 5 error, got %v", err)
+	}
+	bf, err := ReadBankFileFrom(strings.NewReader(doc), "s.xml", "b", Options{Lenient: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bf.Rows) != 1 || bf.Rows[0].UniqueIdentifier != "A1" || bf.Rows[0].AmountMinor != -1250 || bf.Rows[0].Date.Format("2006-01-02") != "2024-01-02" {
+		t.Fatalf("unexpected rows: %+v", bf.Rows)
+	}
+	if bf.Meta.AccountNumber != "1234567" || len(bf.Rejects) != 1 || bf.Rejects[0].Line != 5 {
+		t.Fatalf("unexpected meta/rejects: %+v %+v", bf.Meta, bf.Rejects)
+	}
+
+	// UTF-16 XML (BOM FF FE) is decoded before the format is sniffed
+	wide := []byte{0xFF, 0xFE}
+	for _, u := range utf16.Encode([]rune(strings.Replace(doc, `version="1.0"`, `version="1.0" encoding="UTF-16"`, 1))) {
+		wide = append(wide, byte(u), byte(u>>8))
+	}
+	bf, err = ReadBankFileFrom(bytes.NewReader(wide), "s16.xml", "b", Options{Lenient: true})
+	if err != nil || len(bf.Rows) != 1 || bf.Rows[0].UniqueIdentifier != "A1" || len(bf.Rejects) != 1 {
+		t.Fatalf("utf-16: %v %+v", err, bf)
+	}
+
+	// CSV content still goes to the CSV parser
+	bf, err = ReadBankFileFrom(strings.NewReader("unique_identifier,amount,date\nX,1.00,2024-01-01\n"), "b.csv", "b", Options{})
+	if err != nil || len(bf.Rows) != 1 {
+		t.Fatalf("csv: %v %+v", err, bf)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"strconv"
 	"strings"
 	"time"
 
@@ -17,33 +16,147 @@ import (
 type BankFile struct {
 	BankName string
 	Rows     []models.BankStatement
+	// Meta holds account number, period and balances from preamble/trailer rows
+	Meta StatementMeta
+	// Rejects lists rows skipped in lenient mode
+	Rejects []Reject
+}
+
+// SystemFile is the result of reading a system CSV
+type SystemFile struct {
+	Rows []models.SystemTransaction
+	// Rejects lists rows skipped in lenient mode
+	Rejects []Reject
+}
+
+// Options tunes how a source file is read. The zero value keeps the defaults documented on each reader.
+type Options struct {
+	// Location is used for timestamps without an offset; nil means UTC
+	Location *time.Location
+	// Currency applies to rows without a currency column value; empty means models.DefaultCurrency
+	Currency string
+	// NumberFormat selects the decimal separator of amounts; empty means NumberAuto
+	NumberFormat NumberFormat
+	// Rounding handles amounts with more fractional digits than the currency allows; empty means RoundTruncate
+	Rounding RoundingMode
+	// AmountLayout selects the amount columns of a bank file; empty means LayoutSigned
+	AmountLayout AmountLayout
+	// DateLayouts are Go time layouts for bank dates, tried in order; empty means "2006-01-02".
+	// A value that parses to different dates under two layouts is rejected as ambiguous.
+	DateLayouts []string
+	// Columns maps canonical fields (e.g. "amount") to source header aliases; see Profile
+	Columns map[string][]string
+	// Encoding of the file; empty means EncodingAuto
+	Encoding Encoding
+	// Delimiter of the CSV fields; 0 means detect among , ; tab |
+	Delimiter rune
+	// Attributes selects the extra (non-canonical) columns kept in row Attributes, matched
+	// case-insensitively; nil keeps every extra column
+	Attributes []string
+	// Lenient skips invalid rows and records them as rejects instead of failing the read
+	Lenient bool
+	// MaxRejectPercent fails a lenient read when more than this percent of rows is rejected; 0 means no limit
+	MaxRejectPercent float64
+}
+
+// AmountLayout names how a bank file carries the sign of an amount
+type AmountLayout string
+
+const (
+	LayoutSigned      AmountLayout = "signed"       // one signed amount column (default)
+	LayoutDebitCredit AmountLayout = "debit-credit" // separate debit and credit columns, one of them filled
+	LayoutIndicator   AmountLayout = "indicator"    // amount plus an indicator column (DB/CR, D/K)
+)
+
+// ParseAmountLayout validates a layout name; empty means LayoutSigned
+func ParseAmountLayout(s string) (AmountLayout, error) {
+	switch l := AmountLayout(strings.ToLower(strings.TrimSpace(s))); l {
+	case "":
+		return LayoutSigned, nil
+	case LayoutSigned, LayoutDebitCredit, LayoutIndicator:
+		return l, nil
+	default:
+		return "", fmt.Errorf("unknown amount layout: %s", s)
+	}
+}
+
+// amountColumns lists the columns a layout requires
+func (l AmountLayout) amountColumns() []string {
+	switch l {
+	case LayoutDebitCredit:
+		return []string{"debit", "credit"}
+	case LayoutIndicator:
+		return []string{"amount", "indicator"}
+	default:
+		return []string{"amount"}
+	}
+}
+
+// rowCurrency returns the normalized currency of a record and its minor-unit exponent
+func rowCurrency(rec []string, col map[string]int, opts Options) (string, int, error) {
+	code := opts.Currency
+	if i, ok := col["currency"]; ok && strings.TrimSpace(rec[i]) != "" {
+		code = rec[i]
+	}
+	code = models.NormalizeCurrency(code)
+	exp, err := models.CurrencyExponent(code)
+	if err != nil {
+		return "", 0, err
+	}
+	return code, exp, nil
 }
 
 // ReadSystemTransactions reads CSV with headers:
-// trxID,amount,type,transactionTime
-// amount: decimal string, parsed into minor units (x100)
+// trxID,amount,type,transactionTime[,currency]
+// amount: decimal string, parsed into minor units of the row currency (ISO 4217 exponent)
 // transactionTime: RFC3339 or "2006-01-02 15:04:05" or "2006-01-02"
 func ReadSystemTransactions(path string) ([]models.SystemTransaction, error) {
+	return ReadSystemTransactionsWithOptions(path, Options{})
+}
+
+// ReadSystemTransactionsWithOptions is ReadSystemTransactions with per-source options
+func ReadSystemTransactionsWithOptions(path string, opts Options) ([]models.SystemTransaction, error) {
+	sf, err := ReadSystemFile(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	return sf.Rows, nil
+}
+
+// ReadSystemFile is ReadSystemTransactionsWithOptions that also returns the rows rejected in lenient mode
+func ReadSystemFile(path string, opts Options) (*SystemFile, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
+	return ReadSystemFileFrom(f, path, opts)
+}
 
-	r := csv.NewReader(f)
-	r.TrimLeadingSpace = true
+// ReadSystemFileFrom reads system transactions from src; name identifies the source in row
+// provenance and rejects (e.g. "-" for stdin)
+func ReadSystemFileFrom(src io.Reader, name string, opts Options) (*SystemFile, error) {
+	r, err := newCSVReader(src, opts)
+	if err != nil {
+		return nil, err
+	}
+	// short rows are reported per row instead of failing the reader
+	r.FieldsPerRecord = -1
 	headers, err := r.Read()
 	if err != nil {
 		return nil, fmt.Errorf("read header: %w", err)
 	}
-	col := toIndex(headers)
+	col := toIndex(headers, opts.Columns)
 	required := []string{"trxID", "amount", "type", "transactionTime"}
 	for _, k := range required {
 		if _, ok := col[k]; !ok {
 			return nil, fmt.Errorf("missing column: %s", k)
 		}
 	}
+	width := columnWidth(col)
+	extra := extraColumns(headers, col, opts.Attributes)
 
+	errs := rowErrors{file: name, opts: opts}
 	var out []models.SystemTransaction
 	for {
 		rec, err := r.Read()
@@ -51,61 +164,110 @@ func ReadSystemTransactions(path string) ([]models.SystemTransaction, error) {
 			break
 		}
 		if err != nil {
-			return nil, fmt.Errorf("read row: %w", err)
+			if err := errs.readError(err); err != nil {
+				return nil, err
+			}
+			continue
 		}
-		trxID := rec[col["trxID"]]
-		amountStr := rec[col["amount"]]
-		typStr := strings.ToUpper(strings.TrimSpace(rec[col["type"]]))
-		timeStr := rec[col["transactionTime"]]
-
-		amountMinor, err := parseDecimalToMinor(amountStr)
-		if err != nil {
-			return nil, fmt.Errorf("row trxID=%s amount parse: %w", trxID, err)
+		errs.rows++
+		line, _ := r.FieldPos(0)
+		if len(rec) < width {
+			if err := errs.add(line, rec, fmt.Errorf("has %d fields, header has %d", len(rec), width)); err != nil {
+				return nil, err
+			}
+			continue
 		}
-		tt, err := parseTimeFlexible(timeStr)
+		s, err := systemRow(rec, col, opts)
 		if err != nil {
-			return nil, fmt.Errorf("row trxID=%s time parse: %w", trxID, err)
-		}
-		typ := models.TransactionType(typStr)
-		switch typ {
-		case models.TypeDebit, models.TypeCredit:
-		default:
-			return nil, fmt.Errorf("row trxID=%s invalid type: %s", trxID, typStr)
+			if err := errs.add(line, rec, err); err != nil {
+				return nil, err
+			}
+			continue
 		}
-		out = append(out, models.SystemTransaction{
-			TrxID:           trxID,
-			AmountMinor:     amountMinor,
-			Type:            typ,
-			TransactionTime: tt,
-		})
+		s.Source = models.Source{Path: name, Line: line, RecordHash: recordHash(rec)}
+		s.Attributes = rowAttributes(rec, extra)
+		out = append(out, s)
+	}
+	if err := errs.check(); err != nil {
+		return nil, err
 	}
-	return out, nil
+	return &SystemFile{Rows: out, Rejects: errs.rejects}, nil
+}
+
+// systemRow parses one system record
+func systemRow(rec []string, col map[string]int, opts Options) (models.SystemTransaction, error) {
+	trxID := rec[col["trxID"]]
+	amountStr := rec[col["amount"]]
+	typStr := strings.ToUpper(strings.TrimSpace(rec[col["type"]]))
+	timeStr := rec[col["transactionTime"]]
+
+	currency, exp, err := rowCurrency(rec, col, opts)
+	if err != nil {
+		return models.SystemTransaction{}, fmt.Errorf("trxID=%s currency: %w", trxID, err)
+	}
+	amountMinor, err := parseDecimalToMinor(amountStr, exp, opts.NumberFormat, opts.Rounding)
+	if err != nil {
+		return models.SystemTransaction{}, fmt.Errorf("trxID=%s amount parse: %w", trxID, err)
+	}
+	tt, err := parseTimeFlexible(timeStr, opts.Location)
+	if err != nil {
+		return models.SystemTransaction{}, fmt.Errorf("trxID=%s time parse: %w", trxID, err)
+	}
+	typ := models.TransactionType(typStr)
+	switch typ {
+	case models.TypeDebit, models.TypeCredit:
+	default:
+		return models.SystemTransaction{}, fmt.Errorf("trxID=%s invalid type: %s", trxID, typStr)
+	}
+	return models.SystemTransaction{
+		TrxID:           trxID,
+		AmountMinor:     amountMinor,
+		Currency:        currency,
+		Type:            typ,
+		TransactionTime: tt,
+	}, nil
 }
 
 // ReadBankStatements reads bank CSV with headers:
-// unique_identifier,amount,date
-// amount may be negative for debit
-// date: "2006-01-02"
+// unique_identifier,amount,date[,currency]
+// amount may be negative for debit, also in accounting notation: "(100.00)", "100.00-",
+// "100.00 DR" / "100.00 CR"; currency symbols and codes such as "Rp" are stripped.
+// With Options.AmountLayout the amount columns are debit,credit or amount,indicator instead.
+// date: "2006-01-02" unless Options.DateLayouts is set
 func ReadBankStatements(path string, bankName string) (*BankFile, error) {
+	return ReadBankStatementsWithOptions(path, bankName, Options{})
+}
+
+// ReadBankStatementsWithOptions is ReadBankStatements with per-source options
+func ReadBankStatementsWithOptions(path string, bankName string, opts Options) (*BankFile, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
+	return ReadBankStatementsFrom(f, path, bankName, opts)
+}
 
-	r := csv.NewReader(f)
-	r.TrimLeadingSpace = true
-	headers, err := r.Read()
+// ReadBankStatementsFrom reads a bank statement from src; name identifies the source in row
+// provenance and rejects
+func ReadBankStatementsFrom(src io.Reader, name string, bankName string, opts Options) (*BankFile, error) {
+	r, err := newCSVReader(src, opts)
 	if err != nil {
-		return nil, fmt.Errorf("read header: %w", err)
+		return nil, err
 	}
-	col := toIndex(headers)
-	required := []string{"unique_identifier", "amount", "date"}
-	for _, k := range required {
-		if _, ok := col[k]; !ok {
-			return nil, fmt.Errorf("missing column: %s", k)
-		}
+	// preamble and trailer rows have their own number of fields
+	r.FieldsPerRecord = -1
+
+	var meta StatementMeta
+	required := append([]string{"unique_identifier", "date"}, opts.AmountLayout.amountColumns()...)
+	headers, col, err := findHeader(r, required, opts, &meta)
+	if err != nil {
+		return nil, err
 	}
+	width := columnWidth(col)
+	extra := extraColumns(headers, col, opts.Attributes)
+
+	errs := rowErrors{file: name, opts: opts}
 	var rows []models.BankStatement
 	for {
 		rec, err := r.Read()
@@ -113,101 +275,282 @@ func ReadBankStatements(path string, bankName string) (*BankFile, error) {
 			break
 		}
 		if err != nil {
-			return nil, fmt.Errorf("read row: %w", err)
+			if err := errs.readError(err); err != nil {
+				return nil, err
+			}
+			continue
 		}
-		id := rec[col["unique_identifier"]]
-		amountStr := rec[col["amount"]]
-		dateStr := rec[col["date"]]
-		amountMinor, err := parseDecimalToMinor(amountStr)
-		if err != nil {
-			return nil, fmt.Errorf("row uid=%s amount parse: %w", id, err)
+		if blankRecord(rec) {
+			continue
 		}
-		// date-only normalized to midnight
-		dt, err := time.Parse("2006-01-02", dateStr)
+		line, _ := r.FieldPos(0)
+		// label rows such as Saldo Awal/Saldo Akhir or totals; a row holding an identifier and an amount is data
+		if key, value, ok := metaLabel(rec); ok && labelRow(rec, col, width, opts) {
+			if err := meta.apply(key, value, opts); err != nil {
+				if err := errs.add(line, rec, err); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		errs.rows++
+		if len(rec) < width {
+			if err := errs.add(line, rec, fmt.Errorf("has %d fields, header has %d", len(rec), width)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		row, err := bankRow(rec, col, bankName, opts)
 		if err != nil {
-			return nil, fmt.Errorf("row uid=%s date parse: %w", id, err)
+			if err := errs.add(line, rec, err); err != nil {
+				return nil, err
+			}
+			continue
 		}
-		dt = time.Date(dt.Year(), dt.Month(), dt.Day(), 0, 0, 0, 0, time.UTC)
-		rows = append(rows, models.BankStatement{
-			UniqueIdentifier: id,
-			AmountMinor:      amountMinor,
-			Date:             dt,
-			BankName:         bankName,
-		})
+		row.Source = models.Source{Path: name, Line: line, RecordHash: recordHash(rec)}
+		row.Attributes = rowAttributes(rec, extra)
+		rows = append(rows, row)
+	}
+	if err := errs.check(); err != nil {
+		return nil, err
 	}
 	return &BankFile{
 		BankName: bankName,
 		Rows:     rows,
+		Meta:     meta,
+		Rejects:  errs.rejects,
+	}, nil
+}
+
+// bankRow parses one bank record
+func bankRow(rec []string, col map[string]int, bankName string, opts Options) (models.BankStatement, error) {
+	id := rec[col["unique_identifier"]]
+	currency, exp, err := rowCurrency(rec, col, opts)
+	if err != nil {
+		return models.BankStatement{}, fmt.Errorf("uid=%s currency: %w", id, err)
+	}
+	amountMinor, err := bankAmount(rec, col, currency, exp, opts)
+	if err != nil {
+		return models.BankStatement{}, fmt.Errorf("uid=%s amount parse: %w", id, err)
+	}
+	dt, err := parseBankDate(rec[col["date"]], opts.DateLayouts)
+	if err != nil {
+		return models.BankStatement{}, fmt.Errorf("uid=%s date parse: %w", id, err)
+	}
+	return models.BankStatement{
+		UniqueIdentifier: id,
+		AmountMinor:      amountMinor,
+		Currency:         currency,
+		Date:             dt,
+		BankName:         bankName,
 	}, nil
 }
 
-func toIndex(headers []string) map[string]int {
-	idx := make(map[string]int, len(headers))
+// extraColumns maps the index of each kept non-canonical column to its header
+func extraColumns(headers []string, col map[string]int, keep []string) map[int]string {
+	used := map[int]bool{}
+	for _, f := range canonicalFields {
+		if i, ok := col[f]; ok {
+			used[i] = true
+		}
+	}
+	out := map[int]string{}
 	for i, h := range headers {
-		idx[strings.TrimSpace(h)] = i
+		h = strings.TrimSpace(h)
+		if used[i] || h == "" {
+			continue
+		}
+		if keep == nil {
+			out[i] = h
+			continue
+		}
+		for _, k := range keep {
+			if strings.EqualFold(strings.TrimSpace(k), h) {
+				out[i] = h
+				break
+			}
+		}
+	}
+	return out
+}
+
+// rowAttributes returns the non-empty extra values of a record, nil when there are none
+func rowAttributes(rec []string, extra map[int]string) map[string]string {
+	var attrs map[string]string
+	for i, h := range extra {
+		if i >= len(rec) || strings.TrimSpace(rec[i]) == "" {
+			continue
+		}
+		if attrs == nil {
+			attrs = map[string]string{}
+		}
+		attrs[h] = strings.TrimSpace(rec[i])
+	}
+	return attrs
+}
+
+// columnWidth is the number of fields a data row needs to hold every canonical column found
+func columnWidth(col map[string]int) int {
+	width := 0
+	for _, f := range canonicalFields {
+		if i, ok := col[f]; ok && i+1 > width {
+			width = i + 1
+		}
+	}
+	return width
+}
+
+// labelRow reports whether rec lacks the identifier or every amount column.
+// A lone ":" separator in the identifier column counts as missing.
+func labelRow(rec []string, col map[string]int, width int, opts Options) bool {
+	if len(rec) < width {
+		return true
+	}
+	if strings.Trim(rec[col["unique_identifier"]], " :") == "" {
+		return true
+	}
+	for _, name := range opts.AmountLayout.amountColumns() {
+		if strings.TrimSpace(rec[col[name]]) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// findHeader reads rows until one holds every required column and returns it with its index.
+// Rows before it are preamble; label/value rows among them are stored in meta.
+func findHeader(r *csv.Reader, required []string, opts Options, meta *StatementMeta) ([]string, map[string]int, error) {
+	var firstMissing string
+	for n := 0; ; n++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			if firstMissing == "" {
+				return nil, nil, fmt.Errorf("read header: %w", err)
+			}
+			return nil, nil, fmt.Errorf("missing column: %s", firstMissing)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("read header: %w", err)
+		}
+		col := toIndex(rec, opts.Columns)
+		missing := ""
+		for _, k := range required {
+			if _, ok := col[k]; !ok {
+				missing = k
+				break
+			}
+		}
+		if missing == "" {
+			return rec, col, nil
+		}
+		if n == 0 {
+			firstMissing = missing
+		}
+		if key, value, ok := metaLabel(rec); ok {
+			if err := meta.apply(key, value, opts); err != nil {
+				line, _ := r.FieldPos(0)
+				return nil, nil, fmt.Errorf("row %d: %w", line, err)
+			}
+		}
 	}
-	return idx
 }
 
-// parseDecimalToMinor converts "1234.56" -> 123456 minor units (2 decimals).
-// It tolerates comma or dot as decimal separator, and strips thousand separators.
-func parseDecimalToMinor(s string) (int64, error) {
+// bankAmount derives the signed amount of a record according to opts.AmountLayout.
+// In the debit-credit and indicator layouts the sign comes from the column, not the value.
+func bankAmount(rec []string, col map[string]int, currency string, exp int, opts Options) (int64, error) {
+	parse := func(name string) (int64, error) {
+		return parseBankAmount(rec[col[name]], currency, exp, opts.NumberFormat, opts.Rounding)
+	}
+	switch opts.AmountLayout {
+	case LayoutDebitCredit:
+		// one of the two columns is left empty, which reads as zero
+		parseOptional := func(name string) (int64, error) {
+			if strings.TrimSpace(rec[col[name]]) == "" {
+				return 0, nil
+			}
+			return parse(name)
+		}
+		debit, err := parseOptional("debit")
+		if err != nil {
+			return 0, fmt.Errorf("debit: %w", err)
+		}
+		credit, err := parseOptional("credit")
+		if err != nil {
+			return 0, fmt.Errorf("credit: %w", err)
+		}
+		switch {
+		case strings.TrimSpace(rec[col["debit"]]) == "" && strings.TrimSpace(rec[col["credit"]]) == "":
+			return 0, fmt.Errorf("neither debit nor credit is set")
+		case debit != 0 && credit != 0:
+			return 0, fmt.Errorf("both debit %q and credit %q are set", rec[col["debit"]], rec[col["credit"]])
+		case debit != 0:
+			return -abs64(debit), nil
+		default:
+			return abs64(credit), nil
+		}
+	case LayoutIndicator:
+		v, err := parse("amount")
+		if err != nil {
+			return 0, err
+		}
+		switch ind := strings.ToUpper(strings.TrimSpace(rec[col["indicator"]])); ind {
+		case "DB", "DR", "D", "DEBIT", "DEBET":
+			return -abs64(v), nil
+		case "CR", "C", "K", "CREDIT", "KREDIT":
+			return abs64(v), nil
+		default:
+			return 0, fmt.Errorf("unknown debit/credit indicator: %q", ind)
+		}
+	default:
+		return parse("amount")
+	}
+}
+
+func abs64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// parseBankDate tries every layout and returns the date (normalized to UTC midnight).
+// Layouts that accept the value but disagree on the date, e.g. "02/01/2006" and
+// "01/02/2006" for "03/04/2024", make it ambiguous.
+func parseBankDate(s string, layouts []string) (time.Time, error) {
 	s = strings.TrimSpace(s)
-	if s == "" {
-		return 0, nil
-	}
-	neg := false
-	if strings.HasPrefix(s, "-") {
-		neg = true
-		s = s[1:]
-	} else if strings.HasPrefix(s, "+") {
-		s = s[1:]
-	}
-	// Normalize decimal separator: if both '.' and ',' exist, assume ',' is thousands and '.' is decimal
-	// If only ',' exists, treat it as decimal
-	if strings.Contains(s, ".") && strings.Contains(s, ",") {
-		s = strings.ReplaceAll(s, ",", "")
-	} else if strings.Contains(s, ",") && !strings.Contains(s, ".") {
-		s = strings.ReplaceAll(s, ",", ".")
-	}
-	// Remove any stray spaces/quotes
-	s = strings.ReplaceAll(s, " ", "")
-	s = strings.Trim(s, "\"")
-
-	parts := strings.SplitN(s, ".", 2)
-	intPart := parts[0]
-	fracPart := ""
-	if len(parts) == 2 {
-		fracPart = parts[1]
-	}
-	// Remove thousand separators if any left
-	intPart = strings.ReplaceAll(intPart, ",", "")
-
-	if intPart == "" {
-		intPart = "0"
-	}
-	if len(fracPart) > 2 {
-		fracPart = fracPart[:2]
-	}
-	for len(fracPart) < 2 {
-		fracPart += "0"
-	}
-	full := intPart + fracPart
-	if full == "" {
-		full = "0"
-	}
-	v, err := strconv.ParseInt(full, 10, 64)
-	if err != nil {
-		return 0, err
+	if len(layouts) == 0 {
+		layouts = []string{"2006-01-02"}
+	}
+	var found time.Time
+	var foundLayout string
+	for _, l := range layouts {
+		t, err := time.Parse(l, s)
+		if err != nil {
+			continue
+		}
+		// date-only normalized to midnight
+		d := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		if foundLayout == "" {
+			found, foundLayout = d, l
+			continue
+		}
+		if !d.Equal(found) {
+			return time.Time{}, fmt.Errorf("ambiguous date %q: %s gives %s but %s gives %s", s,
+				foundLayout, found.Format("2006-01-02"), l, d.Format("2006-01-02"))
+		}
 	}
-	if neg {
-		v = -v
+	if foundLayout == "" {
+		return time.Time{}, fmt.Errorf("date %q matches none of the layouts %q", s, layouts)
 	}
-	return v, nil
+	return found, nil
 }
 
-func parseTimeFlexible(s string) (time.Time, error) {
+// parseTimeFlexible keeps RFC3339 offsets; other layouts are read in loc (UTC when nil)
+func parseTimeFlexible(s string, loc *time.Location) (time.Time, error) {
 	s = strings.TrimSpace(s)
+	if loc == nil {
+		loc = time.UTC
+	}
 	formats := []string{
 		time.RFC3339,
 		"2006-01-02 15:04:05",
@@ -215,17 +558,11 @@ func parseTimeFlexible(s string) (time.Time, error) {
 	}
 	var lastErr error
 	for _, f := range formats {
-		t, err := time.Parse(f, s)
+		t, err := time.ParseInLocation(f, s, loc)
 		if err == nil {
-			// Normalize to UTC
-			if f == "2006-01-02" {
-				t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
-			}
 			return t, nil
 		}
 		lastErr = err
 	}
 	return time.Time{}, fmt.Errorf("time parse failed: %w", lastErr)
 }
-
-
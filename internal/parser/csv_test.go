@@ -0,0 +1,555 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"recon-service/internal/models"
+)
+
+// Note: Comments in English per instruction
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return p
+}
+
+func TestReadBankStatements_CurrencyExponent(t *testing.T) {
+	p := writeFile(t, "bank.csv", "unique_identifier,amount,date,currency\n"+
+		"A,1500,2024-01-01,JPY\n"+
+		"B,1.250,2024-01-01,KWD\n"+
+		"C,10.50,2024-01-01,\n"+
+		"D,-3.25,2024-01-01,usd\n")
+
+	// "1.250" needs an explicit format: with auto-detection it is ambiguous
+	bf, err := ReadBankStatementsWithOptions(p, "bank", Options{Currency: "SGD", NumberFormat: NumberDot})
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	want := []struct {
+		amount   int64
+		currency string
+	}{
+		{1500, "JPY"},
+		{1250, "KWD"},
+		{1050, "SGD"}, // falls back to the source default
+		{-325, "USD"},
+	}
+	for i, w := range want {
+		r := bf.Rows[i]
+		if r.AmountMinor != w.amount || r.Currency != w.currency {
+			t.Fatalf("row %d got=%d %s want=%d %s", i, r.AmountMinor, r.Currency, w.amount, w.currency)
+		}
+	}
+
+	bad := writeFile(t, "bad.csv", "unique_identifier,amount,date,currency\nA,1,2024-01-01,XXX\n")
+	if _, err := ReadBankStatements(bad, "bank"); err == nil {
+		t.Fatalf("expected error for unknown currency")
+	}
+}
+
+func TestParseDecimalToMinor_RoundingModes(t *testing.T) {
+	cases := []struct {
+		in   string
+		mode RoundingMode
+		want int64
+	}{
+		{"10.999", RoundTruncate, 1099},
+		{"10.999", "", 1099},
+		{"10.995", RoundHalfUp, 1100},
+		{"-10.995", RoundHalfUp, -1100},
+		{"10.994", RoundHalfUp, 1099},
+		{"10.125", RoundHalfEven, 1012},
+		{"10.135", RoundHalfEven, 1014},
+		{"10.1251", RoundHalfEven, 1013},
+		{"10.500", RoundError, 1050}, // trailing zeros lose nothing
+	}
+	for _, c := range cases {
+		got, err := parseDecimalToMinor(c.in, 2, NumberDot, c.mode)
+		if err != nil {
+			t.Fatalf("%s (%s): %v", c.in, c.mode, err)
+		}
+		if got != c.want {
+			t.Fatalf("%s (%s) got=%d want=%d", c.in, c.mode, got, c.want)
+		}
+	}
+	if _, err := parseDecimalToMinor("10.999", 2, NumberDot, RoundError); err == nil {
+		t.Fatalf("expected precision error")
+	}
+}
+
+func TestReadSystemTransactions_StrictReportsRow(t *testing.T) {
+	p := writeFile(t, "system.csv", "trxID,amount,type,transactionTime\n"+
+		"T1,10.00,CREDIT,2024-01-01\n"+
+		"T2,10.999,CREDIT,2024-01-01\n")
+
+	if _, err := ReadSystemTransactionsWithOptions(p, Options{NumberFormat: NumberDot}); err != nil {
+		t.Fatalf("default mode should truncate: %v", err)
+	}
+	_, err := ReadSystemTransactionsWithOptions(p, Options{NumberFormat: NumberDot, Rounding: RoundError})
+	if err == nil || !strings.Contains(err.Error(), "row 3 ") {
+		t.Fatalf("expected error on row 3, got %v", err)
+	}
+}
+
+func TestParseDecimalToMinor_NumberFormats(t *testing.T) {
+	cases := []struct {
+		in     string
+		format NumberFormat
+		want   int64
+	}{
+		{"1.234.567,89", NumberAuto, 123456789},
+		{"1,234,567.89", NumberAuto, 123456789},
+		{"1.234.567", NumberAuto, 123456700},
+		{"12,5", NumberAuto, 1250},
+		{"0.125", NumberAuto, 12},
+		{"1234.567", NumberAuto, 123456},
+		{"1.234", NumberComma, 123400},
+		{"1.234", NumberDot, 123},
+		{"1.234.567,89", NumberComma, 123456789},
+		{"-1,234.50", NumberDot, -123450},
+	}
+	for _, c := range cases {
+		got, err := parseDecimalToMinor(c.in, 2, c.format, RoundTruncate)
+		if err != nil {
+			t.Fatalf("%s (%s): %v", c.in, c.format, err)
+		}
+		if got != c.want {
+			t.Fatalf("%s (%s) got=%d want=%d", c.in, c.format, got, c.want)
+		}
+	}
+
+	bad := []struct {
+		in     string
+		format NumberFormat
+	}{
+		{"1.234", NumberAuto},         // ambiguous
+		{"1,234", NumberAuto},         // ambiguous
+		{"1,23", NumberDot},           // not a thousands group
+		{"1.234,56", NumberDot},       // comma after the decimal dot
+		{"12.34.56", NumberAuto},      // broken grouping
+		{"1.234.567,89", NumberDot},   // wrong format for the source
+		{"1,234,567.89", NumberComma}, // wrong format for the source
+	}
+	for _, c := range bad {
+		if v, err := parseDecimalToMinor(c.in, 2, c.format, RoundTruncate); err == nil {
+			t.Fatalf("%s (%s): expected error, got %d", c.in, c.format, v)
+		}
+	}
+}
+
+func TestParseBankAmount_AccountingNotations(t *testing.T) {
+	cases := []struct {
+		in       string
+		currency string
+		want     int64
+	}{
+		{"(100.00)", "IDR", -10000},
+		{"100.00-", "IDR", -10000},
+		{"100.00 DR", "IDR", -10000},
+		{"100.00DB", "IDR", -10000},
+		{"100.00 CR", "IDR", 10000},
+		{"Rp 100.000,00", "IDR", 10000000},
+		{"Rp. 100.000,00", "IDR", 10000000},
+		{"Rp.1.500,00", "IDR", 150000},
+		{"-Rp. 50,00", "IDR", -5000},
+		{"(Rp 1.500,00)", "IDR", -150000},
+		{"-Rp 50,00", "IDR", -5000},
+		{"IDR 100.50", "IDR", 10050},
+		{"100.50 usd", "USD", 10050},
+		{"US$ 12.34", "USD", 1234},
+		{"$ 1,250.00 CR", "SGD", 125000},
+		{"-75.10", "IDR", -7510},
+	}
+	for _, c := range cases {
+		exp, _ := models.CurrencyExponent(c.currency)
+		got, err := parseBankAmount(c.in, c.currency, exp, NumberAuto, RoundTruncate)
+		if err != nil {
+			t.Fatalf("%q: %v", c.in, err)
+		}
+		if got != c.want {
+			t.Fatalf("%q got=%d want=%d", c.in, got, c.want)
+		}
+	}
+
+	for _, in := range []string{"(100.00) DR", "-100.00 CR", "100.00 XYZ", "USD 100.00", "100.00 ABC DR",
+		"", "()", "( )", "Rp", "Rp.", "Rp -", "$", "IDR", "-", ".", ","} {
+		if v, err := parseBankAmount(in, "IDR", 2, NumberAuto, RoundTruncate); err == nil {
+			t.Fatalf("%q: expected error, got %d", in, v)
+		}
+	}
+}
+
+func TestReadBankStatements_AmountLayouts(t *testing.T) {
+	dc := writeFile(t, "dc.csv", "unique_identifier,date,debit,credit\n"+
+		"A,2024-01-01,\"1.500,00\",\n"+
+		"B,2024-01-01,,\"250.000,00\"\n")
+	bf, err := ReadBankStatementsWithOptions(dc, "bank", Options{AmountLayout: LayoutDebitCredit})
+	if err != nil {
+		t.Fatalf("read debit-credit: %v", err)
+	}
+	if bf.Rows[0].AmountMinor != -150000 || bf.Rows[1].AmountMinor != 25000000 {
+		t.Fatalf("debit-credit amounts got=%d,%d", bf.Rows[0].AmountMinor, bf.Rows[1].AmountMinor)
+	}
+
+	ind := writeFile(t, "ind.csv", "unique_identifier,date,amount,indicator\n"+
+		"A,2024-01-01,100.00,DB\n"+
+		"B,2024-01-01,100.00,cr\n"+
+		"C,2024-01-01,100.00,K\n")
+	bf, err = ReadBankStatementsWithOptions(ind, "bank", Options{AmountLayout: LayoutIndicator})
+	if err != nil {
+		t.Fatalf("read indicator: %v", err)
+	}
+	for i, want := range []int64{-10000, 10000, 10000} {
+		if bf.Rows[i].AmountMinor != want {
+			t.Fatalf("indicator row %d got=%d want=%d", i, bf.Rows[i].AmountMinor, want)
+		}
+	}
+
+	both := writeFile(t, "both.csv", "unique_identifier,date,debit,credit\nA,2024-01-01,1.00,2.00\n")
+	if _, err := ReadBankStatementsWithOptions(both, "bank", Options{AmountLayout: LayoutDebitCredit}); err == nil {
+		t.Fatalf("expected error when debit and credit are both set")
+	}
+	neither := writeFile(t, "neither.csv", "unique_identifier,date,debit,credit\nA,2024-01-01,,\n")
+	if _, err := ReadBankStatementsWithOptions(neither, "bank", Options{AmountLayout: LayoutDebitCredit}); err == nil {
+		t.Fatalf("expected error when neither debit nor credit is set")
+	}
+	blank := writeFile(t, "blank.csv", "unique_identifier,amount,date\nA,,2024-01-01\n")
+	if _, err := ReadBankStatements(blank, "bank"); err == nil {
+		t.Fatalf("expected error for a blank signed amount")
+	}
+	if _, err := ReadBankStatements(dc, "bank"); err == nil {
+		t.Fatalf("expected missing amount column in the signed layout")
+	}
+}
+
+func TestLoadProfiles_MapsHeaders(t *testing.T) {
+	cfg := writeFile(t, "profiles.json", `{"profiles": {
+		"bca": {
+			"columns": {
+				"unique_identifier": ["No. Referensi", "Ref"],
+				"amount": ["Nominal"],
+				"date": ["Tanggal Transaksi"]
+			},
+			"numberFormat": "comma"
+		}
+	}}`)
+	profiles, err := LoadProfiles(cfg)
+	if err != nil {
+		t.Fatalf("load profiles: %v", err)
+	}
+	p := writeFile(t, "bca.csv", "Tanggal Transaksi,NOMINAL,no. referensi\n"+
+		"2024-01-05,\"1.234.567,89\",TX-001\n")
+
+	bf, err := ReadBankStatementsWithOptions(p, "bank_bca", profiles["bca"].Apply(Options{}))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	r := bf.Rows[0]
+	if r.UniqueIdentifier != "TX-001" || r.AmountMinor != 123456789 || r.Date.Format("2006-01-02") != "2024-01-05" {
+		t.Fatalf("unexpected row: %+v", r)
+	}
+
+	bad := writeFile(t, "bad.json", `{"profiles": {"x": {"columns": {"nominal": ["Nominal"]}}}}`)
+	if _, err := LoadProfiles(bad); err == nil {
+		t.Fatalf("expected error for an unknown canonical field")
+	}
+}
+
+func TestParseBankDate_Layouts(t *testing.T) {
+	cases := []struct {
+		in      string
+		layouts []string
+		want    string
+	}{
+		{"2024-01-02", nil, "2024-01-02"},
+		{"02/01/2024", []string{"02/01/2006"}, "2024-01-02"},
+		{"02-Jan-24", []string{"02/01/2006", "02-Jan-06"}, "2024-01-02"},
+		{"02/01/2024 23:15:00", []string{"02/01/2006 15:04:05"}, "2024-01-02"},
+		{"13/01/2024", []string{"02/01/2006", "01/02/2006"}, "2024-01-13"}, // only one order fits
+		{"05/05/2024", []string{"02/01/2006", "01/02/2006"}, "2024-05-05"}, // both agree
+	}
+	for _, c := range cases {
+		got, err := parseBankDate(c.in, c.layouts)
+		if err != nil {
+			t.Fatalf("%s %v: %v", c.in, c.layouts, err)
+		}
+		if got.Format("2006-01-02") != c.want || got.Location() != time.UTC || got.Hour() != 0 {
+			t.Fatalf("%s %v got=%v want=%s", c.in, c.layouts, got, c.want)
+		}
+	}
+
+	if _, err := parseBankDate("02/01/2024", []string{"02/01/2006", "01/02/2006"}); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Fatalf("expected ambiguous day/month error, got %v", err)
+	}
+	if _, err := parseBankDate("02/01/2024", nil); err == nil {
+		t.Fatalf("expected error for a layout that is not configured")
+	}
+}
+
+func TestReadBankStatements_PreambleAndTrailer(t *testing.T) {
+	p := writeFile(t, "bca.csv", "Informasi Rekening - Mutasi Rekening\n"+
+		"No. Rekening,:,1234567890\n"+
+		"Periode : 01/01/2024 - 31/01/2024\n"+
+		"Mata Uang,:,IDR\n"+
+		"\n"+
+		"Tanggal Transaksi,No. Referensi,Nominal\n"+
+		"Saldo Awal,,\"1.000.000,00\"\n"+
+		"05/01/2024,TX-001,\"100.000,00\"\n"+
+		"06/01/2024,TOTAL-1,\"-50.000,00\"\n"+
+		"\n"+
+		"Mutasi Kredit,:,\"100.000,00\"\n"+
+		"Saldo Akhir,:,\"1.050.000,00\"\n")
+	opts := Options{
+		Columns: map[string][]string{
+			"unique_identifier": {"No. Referensi"},
+			"amount":            {"Nominal"},
+			"date":              {"Tanggal Transaksi"},
+		},
+		NumberFormat: NumberComma,
+		DateLayouts:  []string{"02/01/2006"},
+	}
+	bf, err := ReadBankStatementsWithOptions(p, "bank_bca", opts)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(bf.Rows) != 2 || bf.Rows[0].UniqueIdentifier != "TX-001" || bf.Rows[1].UniqueIdentifier != "TOTAL-1" {
+		t.Fatalf("unexpected rows: %+v", bf.Rows)
+	}
+	m := bf.Meta
+	if m.AccountNumber != "1234567890" || m.Currency != "IDR" || m.Period != "01/01/2024 - 31/01/2024" {
+		t.Fatalf("unexpected meta: %+v", m)
+	}
+	if m.PeriodStart.Format("2006-01-02") != "2024-01-01" || m.PeriodEnd.Format("2006-01-02") != "2024-01-31" {
+		t.Fatalf("unexpected period: %v - %v", m.PeriodStart, m.PeriodEnd)
+	}
+	if m.OpeningBalanceMinor == nil || *m.OpeningBalanceMinor != 100000000 || m.ClosingBalanceMinor == nil || *m.ClosingBalanceMinor != 105000000 {
+		t.Fatalf("unexpected balances: %v %v", m.OpeningBalanceMinor, m.ClosingBalanceMinor)
+	}
+}
+
+func TestReadBankStatements_LabelLikeDataRows(t *testing.T) {
+	p := writeFile(t, "bank.csv", "unique_identifier,amount,date\n"+
+		"TX-1,10.00,2024-01-01\n"+
+		"Total-99,5.00,2024-13-01\n")
+	if _, err := ReadBankStatements(p, "bank_a"); err == nil || !strings.Contains(err.Error(), "row 3 ") {
+		t.Fatalf("strict read should fail on row 3, got %v", err)
+	}
+
+	q := writeFile(t, "bank_date_first.csv", "date,description,amount,unique_identifier\n"+
+		"2024-01-01,Transfer,10.00,A\n"+
+		",Total transfer,5.00,B\n"+
+		"Saldo Akhir,,,\n")
+	bf, err := ReadBankStatementsWithOptions(q, "bank_b", Options{Lenient: true})
+	if err != nil {
+		t.Fatalf("lenient read: %v", err)
+	}
+	if len(bf.Rows) != 1 || bf.Rows[0].UniqueIdentifier != "A" {
+		t.Fatalf("unexpected rows: %+v", bf.Rows)
+	}
+	if len(bf.Rejects) != 1 || bf.Rejects[0].Line != 3 {
+		t.Fatalf("label-like data row should be rejected, got %+v", bf.Rejects)
+	}
+}
+
+func TestReadSystemFile_LenientRejects(t *testing.T) {
+	p := writeFile(t, "system.csv", "trxID,amount,type,transactionTime\n"+
+		"T1,10.00,CREDIT,2024-01-01\n"+
+		"T2,abc,CREDIT,2024-01-01\n"+
+		"T3,10.00,REFUND,2024-01-01\n"+
+		"T4,10.00\n"+
+		"T5,5.00,DEBIT,2024-01-02\n")
+
+	if _, err := ReadSystemTransactions(p); err == nil || !strings.Contains(err.Error(), "row 3 ") {
+		t.Fatalf("strict read should fail on row 3, got %v", err)
+	}
+
+	sf, err := ReadSystemFile(p, Options{Lenient: true})
+	if err != nil {
+		t.Fatalf("lenient read: %v", err)
+	}
+	if len(sf.Rows) != 2 || sf.Rows[0].TrxID != "T1" || sf.Rows[1].TrxID != "T5" {
+		t.Fatalf("unexpected rows: %+v", sf.Rows)
+	}
+	wantLines := []int{3, 4, 5}
+	if len(sf.Rejects) != len(wantLines) {
+		t.Fatalf("unexpected rejects: %+v", sf.Rejects)
+	}
+	for i, rj := range sf.Rejects {
+		if rj.File != p || rj.Line != wantLines[i] || rj.Reason == "" || len(rj.Record) == 0 {
+			t.Fatalf("reject %d unexpected: %+v", i, rj)
+		}
+	}
+
+	// 3 of 5 rows rejected
+	if _, err := ReadSystemFile(p, Options{Lenient: true, MaxRejectPercent: 50}); err == nil {
+		t.Fatalf("expected error above the max reject rate")
+	}
+
+	var buf strings.Builder
+	if err := WriteRejectsCSV(&buf, sf.Rejects); err != nil {
+		t.Fatalf("write rejects: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "file,line,reason,record\n") || !strings.Contains(buf.String(), `"T4,10.00"`) {
+		t.Fatalf("unexpected rejects csv:\n%s", buf.String())
+	}
+}
+
+func TestReadSystemTransactions_EncodingAndDelimiter(t *testing.T) {
+	rows := []string{"trxID;amount;type;transactionTime", "T1;1.234,50;CREDIT;2024-01-01"}
+	bom := writeFile(t, "bom.csv", "\xEF\xBB\xBF"+strings.Join(rows, "\r\n")+"\r\n")
+
+	utf16le := func(s string) string {
+		b := []byte{0xFF, 0xFE}
+		for _, u := range utf16.Encode([]rune(s)) {
+			b = append(b, byte(u), byte(u>>8))
+		}
+		return string(b)
+	}
+	tabbed := strings.ReplaceAll(strings.Join(rows, "\n"), ";", "\t")
+	wide := writeFile(t, "utf16.csv", utf16le(tabbed+"\n"))
+
+	// "Caf\xe9" is Windows-1252 for "Café"
+	cp := writeFile(t, "cp1252.csv", "trxID|amount|type|transactionTime\nCaf\xe9-1|1.234,50|CREDIT|2024-01-01\n")
+
+	cases := []struct {
+		path string
+		id   string
+	}{
+		{bom, "T1"},
+		{wide, "T1"},
+		{cp, "Café-1"},
+	}
+	for _, c := range cases {
+		got, err := ReadSystemTransactionsWithOptions(c.path, Options{NumberFormat: NumberComma})
+		if err != nil {
+			t.Fatalf("%s: %v", c.path, err)
+		}
+		if len(got) != 1 || got[0].TrxID != c.id || got[0].AmountMinor != 123450 {
+			t.Fatalf("%s: unexpected rows %+v", c.path, got)
+		}
+	}
+
+	// explicit overrides win over detection
+	if _, err := ReadSystemTransactionsWithOptions(bom, Options{Delimiter: ','}); err == nil {
+		t.Fatalf("expected missing columns with a forced comma delimiter")
+	}
+}
+
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestNewCSVReader_Streams(t *testing.T) {
+	var text strings.Builder
+	text.WriteString("trxID;note\n")
+	for i := 0; text.Len() < 4*sniffSize; i++ {
+		fmt.Fprintf(&text, "T%d;Café 😀\n", i)
+	}
+	raw := []byte{0xFF, 0xFE}
+	for _, u := range utf16.Encode([]rune(text.String())) {
+		raw = append(raw, byte(u), byte(u>>8))
+	}
+	src := &countingReader{r: bytes.NewReader(raw)}
+	r, err := newCSVReader(src, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.n >= len(raw) {
+		t.Fatalf("detection read the whole input (%d bytes)", src.n)
+	}
+	if r.Comma != ';' {
+		t.Fatalf("got delimiter %q", r.Comma)
+	}
+	got, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Count(text.String(), "\n"); len(got) != want || got[len(got)-1][1] != "Café 😀" {
+		t.Fatalf("got %d records want %d, last %q", len(got), want, got[len(got)-1])
+	}
+
+	// invalid UTF-8 beyond the detection sample fails instead of being misread
+	bad := strings.Repeat("a,b\n", sniffSize) + "\xff,c\n"
+	r, err = newCSVReader(strings.NewReader(bad), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.ReadAll(); err == nil || !strings.Contains(err.Error(), "not valid UTF-8") {
+		t.Fatalf("expected a UTF-8 error, got %v", err)
+	}
+}
+
+func TestDetectDelimiter_IgnoresPreambleAndQuotes(t *testing.T) {
+	text := "Periode : 01/01/2024 - 31/01/2024, IDR\n" +
+		"Tanggal;Keterangan;Nominal\n" +
+		"01/01/2024;\"Transfer; ref 1\";\"1.000,00\"\n" +
+		"02/01/2024;Setoran;\"2.000,00\"\n"
+	if d := detectDelimiter([]byte(text)); d != ';' {
+		t.Fatalf("got %q want ';'", d)
+	}
+}
+
+func TestReadBankStatements_SourceProvenance(t *testing.T) {
+	comma := writeFile(t, "comma.csv", "unique_identifier,amount,date\nA,1.00,2024-01-01\nB,2.00,2024-01-02\n")
+	semi := writeFile(t, "semi.csv", "unique_identifier;amount;date\n\nA;1.00;2024-01-01\n")
+	a, err := ReadBankStatements(comma, "bank")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ReadBankStatements(semi, "bank")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src := a.Rows[1].Source; src.Path != comma || src.Line != 3 {
+		t.Fatalf("unexpected source: %+v", src)
+	}
+	if b.Rows[0].Source.Line != 3 {
+		t.Fatalf("blank lines must count: %+v", b.Rows[0].Source)
+	}
+	// the hash covers the fields, not the delimiter
+	if a.Rows[0].Source.RecordHash != b.Rows[0].Source.RecordHash || a.Rows[0].Source.RecordHash == a.Rows[1].Source.RecordHash {
+		t.Fatalf("unexpected hashes: %s %s %s", a.Rows[0].Source.RecordHash, b.Rows[0].Source.RecordHash, a.Rows[1].Source.RecordHash)
+	}
+}
+
+func TestReadBankStatements_Attributes(t *testing.T) {
+	p := writeFile(t, "bank.csv", "unique_identifier,Description,amount,Channel,date,Branch\n"+
+		"A,Transfer from PT X,1.00,ATM,2024-01-01,\n")
+
+	all, err := ReadBankStatements(p, "bank")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"Description": "Transfer from PT X", "Channel": "ATM"}
+	got := all.Rows[0].Attributes
+	if len(got) != len(want) || got["Description"] != want["Description"] || got["Channel"] != want["Channel"] {
+		t.Fatalf("all attributes got=%v want=%v", got, want)
+	}
+
+	sel, err := ReadBankStatementsWithOptions(p, "bank", Options{Attributes: []string{"channel"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sel.Rows[0].Attributes; len(got) != 1 || got["Channel"] != "ATM" {
+		t.Fatalf("selected attributes got=%v", got)
+	}
+}
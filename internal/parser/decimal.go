@@ -0,0 +1,307 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"recon-service/internal/models"
+)
+
+// Note: Comments in English per instruction
+
+// RoundingMode decides what happens to fractional digits beyond the currency exponent
+type RoundingMode string
+
+const (
+	RoundTruncate RoundingMode = "truncate" // drop extra digits (default)
+	RoundHalfUp   RoundingMode = "half-up"  // round half away from zero
+	RoundHalfEven RoundingMode = "half-even"
+	RoundError    RoundingMode = "error" // strict: fail whenever precision would be lost
+)
+
+// ParseRoundingMode validates a mode name; empty means RoundTruncate
+func ParseRoundingMode(s string) (RoundingMode, error) {
+	switch m := RoundingMode(strings.ToLower(strings.TrimSpace(s))); m {
+	case "":
+		return RoundTruncate, nil
+	case RoundTruncate, RoundHalfUp, RoundHalfEven, RoundError:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown rounding mode: %s", s)
+	}
+}
+
+// NumberFormat names the decimal and thousands separators used by a source
+type NumberFormat string
+
+const (
+	NumberAuto  NumberFormat = "auto"  // detect per value; ambiguous values are an error (default)
+	NumberDot   NumberFormat = "dot"   // 1,234,567.89
+	NumberComma NumberFormat = "comma" // 1.234.567,89
+)
+
+// ParseNumberFormat validates a format name; empty means NumberAuto
+func ParseNumberFormat(s string) (NumberFormat, error) {
+	switch f := NumberFormat(strings.ToLower(strings.TrimSpace(s))); f {
+	case "":
+		return NumberAuto, nil
+	case NumberAuto, NumberDot, NumberComma:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown number format: %s", s)
+	}
+}
+
+// parseDecimalToMinor converts "1234.56" -> 123456 minor units for exponent 2.
+// Separators are read according to format; thousands separators must form groups of three.
+// Digits beyond exp are handled according to mode.
+func parseDecimalToMinor(s string, exp int, format NumberFormat, mode RoundingMode) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	neg := false
+	if strings.HasPrefix(s, "-") {
+		neg = true
+		s = s[1:]
+	} else if strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+	// Remove any stray spaces/quotes
+	s = strings.ReplaceAll(s, " ", "")
+	s = strings.Trim(s, "\"")
+
+	intPart, fracPart, err := splitDecimal(s, format)
+	if err != nil {
+		return 0, err
+	}
+	if intPart == "" {
+		intPart = "0"
+	}
+	var extra string
+	if len(fracPart) > exp {
+		fracPart, extra = fracPart[:exp], fracPart[exp:]
+	}
+	for len(fracPart) < exp {
+		fracPart += "0"
+	}
+	if strings.Trim(intPart+fracPart+extra, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid amount: %q", s)
+	}
+	v, err := strconv.ParseInt(intPart+fracPart, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if extra != "" {
+		up, err := roundUp(v, extra, mode)
+		if err != nil {
+			return 0, fmt.Errorf("%s has more than %d fractional digits: %w", s, exp, err)
+		}
+		if up {
+			v++
+		}
+	}
+	if neg {
+		v = -v
+	}
+	return v, nil
+}
+
+// splitDecimal returns the integer digits (thousands separators removed) and the fraction digits
+func splitDecimal(s string, format NumberFormat) (string, string, error) {
+	switch format {
+	case NumberDot:
+		return splitWith(s, '.', ',')
+	case NumberComma:
+		return splitWith(s, ',', '.')
+	case "", NumberAuto:
+	default:
+		return "", "", fmt.Errorf("unknown number format: %s", format)
+	}
+
+	dots, commas := strings.Count(s, "."), strings.Count(s, ",")
+	switch {
+	case dots > 0 && commas > 0:
+		// the separator that comes last is the decimal one
+		if strings.LastIndex(s, ".") > strings.LastIndex(s, ",") {
+			return splitWith(s, '.', ',')
+		}
+		return splitWith(s, ',', '.')
+	case dots > 1:
+		return splitWith(s, ',', '.')
+	case commas > 1:
+		return splitWith(s, '.', ',')
+	case dots == 0 && commas == 0:
+		return s, "", nil
+	}
+	// a single separator
+	sep := byte('.')
+	if commas == 1 {
+		sep = ','
+	}
+	i := strings.IndexByte(s, sep)
+	if len(s)-i-1 == 3 && validGroups(s[:i+4], sep) {
+		return "", "", fmt.Errorf("ambiguous amount %q: %c may be a decimal or a thousands separator; set the number format", s, sep)
+	}
+	return s[:i], s[i+1:], nil
+}
+
+// splitWith splits at the decimal separator and removes valid thousands separators
+func splitWith(s string, decimal, thousands byte) (string, string, error) {
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, decimal); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+		if strings.IndexByte(fracPart, decimal) >= 0 || strings.IndexByte(fracPart, thousands) >= 0 {
+			return "", "", fmt.Errorf("invalid amount %q: separator after the decimal %c", s, decimal)
+		}
+	}
+	if strings.IndexByte(intPart, thousands) >= 0 {
+		if !validGroups(intPart, thousands) {
+			return "", "", fmt.Errorf("invalid amount %q: %c is not a valid thousands separator here", s, thousands)
+		}
+		intPart = strings.ReplaceAll(intPart, string(thousands), "")
+	}
+	return intPart, fracPart, nil
+}
+
+// validGroups reports whether s is digits grouped by sep as 1-3 leading digits then groups of three
+func validGroups(s string, sep byte) bool {
+	groups := strings.Split(s, string(sep))
+	if len(groups[0]) < 1 || len(groups[0]) > 3 || (groups[0][0] == '0') {
+		return false
+	}
+	for _, g := range groups[1:] {
+		if len(g) != 3 {
+			return false
+		}
+	}
+	return true
+}
+
+// roundUp reports whether the magnitude v must be incremented given the dropped digits
+func roundUp(v int64, dropped string, mode RoundingMode) (bool, error) {
+	zero := strings.Trim(dropped, "0") == ""
+	switch mode {
+	case "", RoundTruncate:
+		return false, nil
+	case RoundError:
+		if zero {
+			return false, nil
+		}
+		return false, fmt.Errorf("precision would be lost")
+	case RoundHalfUp:
+		return dropped[0] >= '5', nil
+	case RoundHalfEven:
+		switch {
+		case dropped[0] > '5':
+			return true, nil
+		case dropped[0] < '5':
+			return false, nil
+		case strings.Trim(dropped[1:], "0") != "":
+			return true, nil
+		default:
+			// exactly half: round to the even neighbour
+			return v%2 == 1, nil
+		}
+	default:
+		return false, fmt.Errorf("unknown rounding mode: %s", mode)
+	}
+}
+
+// currencySymbols maps common symbols to the currency they imply ("" when ambiguous)
+var currencySymbols = map[string]string{
+	"RP": "IDR", "RP.": "IDR", "$": "", "US$": "USD", "S$": "SGD", "€": "EUR", "£": "GBP", "¥": "", "RM": "MYR",
+}
+
+// parseBankAmount reads a bank amount written in accounting notation:
+// "(100.00)", "100.00-", "100.00 DR" and "-100.00" are negative, "100.00 CR" is positive,
+// and currency symbols or codes ("Rp 100.000,00", "Rp. 100.000,00", "100.00 IDR") are stripped.
+// A code or symbol naming another currency than the row currency is an error, and so is
+// a value without digits (including an empty one).
+func parseBankAmount(s, currency string, exp int, format NumberFormat, mode RoundingMode) (int64, error) {
+	t := strings.TrimSpace(s)
+	neg := false
+	markers := 0
+	for {
+		before := t
+		switch {
+		case strings.HasPrefix(t, "(") && strings.HasSuffix(t, ")"):
+			t, neg = t[1:len(t)-1], true
+			markers++
+		case len(t) > 1 && strings.HasSuffix(t, "-"):
+			t, neg = t[:len(t)-1], true
+			markers++
+		case len(t) > 1 && strings.HasPrefix(t, "-") && !isNumberRune(rune(t[1])):
+			// minus in front of a currency symbol, e.g. "-Rp 100"
+			t, neg = t[1:], true
+			markers++
+		}
+		if lead := strings.TrimSpace(strings.TrimRightFunc(t[:len(t)-len(strings.TrimLeftFunc(t, notNumberRune))], unicode.IsSpace)); lead != "" {
+			if err := checkCurrencyMarker(lead, currency); err != nil {
+				return 0, fmt.Errorf("amount %q: %w", s, err)
+			}
+			t = strings.TrimLeftFunc(t, notNumberRune)
+			// the dot of "Rp." ends the abbreviation, it is not a decimal point
+			if _, ok := currencySymbols[strings.ToUpper(lead)+"."]; ok && strings.HasPrefix(t, ".") {
+				t = strings.TrimSpace(t[1:])
+			}
+		}
+		if trail := strings.TrimSpace(t[len(strings.TrimRightFunc(t, notNumberRune)):]); trail != "" {
+			t = strings.TrimRightFunc(t, notNumberRune)
+			switch strings.ToUpper(trail) {
+			case "DR", "DB", "D":
+				neg = true
+				markers++
+			case "CR", "C":
+				markers++
+			default:
+				if err := checkCurrencyMarker(trail, currency); err != nil {
+					return 0, fmt.Errorf("amount %q: %w", s, err)
+				}
+			}
+		}
+		t = strings.TrimSpace(t)
+		if t == before || t == "" {
+			break
+		}
+	}
+	if markers > 1 || (markers > 0 && strings.HasPrefix(t, "-")) {
+		return 0, fmt.Errorf("amount %q: conflicting sign notations", s)
+	}
+	// garbled cells such as "()", "Rp -" or "." must not read as zero
+	if strings.IndexFunc(t, unicode.IsDigit) < 0 {
+		return 0, fmt.Errorf("amount %q: no digits", s)
+	}
+	v, err := parseDecimalToMinor(t, exp, format, mode)
+	if err != nil {
+		return 0, err
+	}
+	if neg {
+		v = -v
+	}
+	return v, nil
+}
+
+// checkCurrencyMarker accepts a known symbol or ISO code that does not contradict currency
+func checkCurrencyMarker(tok, currency string) error {
+	code, ok := currencySymbols[strings.ToUpper(tok)]
+	if !ok {
+		if _, err := models.CurrencyExponent(tok); err != nil || len(tok) != 3 {
+			return fmt.Errorf("unexpected text %q", tok)
+		}
+		code = strings.ToUpper(tok)
+	}
+	if code != "" && code != models.NormalizeCurrency(currency) {
+		return fmt.Errorf("currency %s does not match row currency %s", tok, models.NormalizeCurrency(currency))
+	}
+	return nil
+}
+
+// isNumberRune reports runes that may appear in the numeric part of an amount
+func isNumberRune(r rune) bool {
+	return unicode.IsDigit(r) || strings.ContainsRune("+-.,()", r)
+}
+
+func notNumberRune(r rune) bool { return !isNumberRune(r) }
@@ -0,0 +1,322 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Note: Comments in English per instruction
+
+// Encoding names the character encoding of a source file
+type Encoding string
+
+const (
+	EncodingAuto        Encoding = "auto" // BOM, then UTF-8 validity, then UTF-16 zero-byte pattern; else Windows-1252 (default)
+	EncodingUTF8        Encoding = "utf-8"
+	EncodingUTF16LE     Encoding = "utf-16le"
+	EncodingUTF16BE     Encoding = "utf-16be"
+	EncodingWindows1252 Encoding = "windows-1252"
+)
+
+// ParseEncoding validates an encoding name; empty means EncodingAuto
+func ParseEncoding(s string) (Encoding, error) {
+	switch e := Encoding(strings.ToLower(strings.TrimSpace(s))); e {
+	case "":
+		return EncodingAuto, nil
+	case "utf8":
+		return EncodingUTF8, nil
+	case "cp1252":
+		return EncodingWindows1252, nil
+	case EncodingAuto, EncodingUTF8, EncodingUTF16LE, EncodingUTF16BE, EncodingWindows1252:
+		return e, nil
+	default:
+		return "", fmt.Errorf("unknown encoding: %s", s)
+	}
+}
+
+// delimiters are the candidates of delimiter detection, in order of preference
+var delimiters = []rune{',', ';', '\t', '|'}
+
+// ParseDelimiter reads ",", ";", "|", "tab" (or "\t"); empty or "auto" gives 0 (detect)
+func ParseDelimiter(s string) (rune, error) {
+	switch strings.ToLower(s) {
+	case "", "auto":
+		return 0, nil
+	case "tab", `\t`, "\t":
+		return '\t', nil
+	}
+	for _, d := range delimiters {
+		if s == string(d) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported delimiter: %q", s)
+}
+
+// sniffSize bounds the sample read ahead for encoding and delimiter detection
+const sniffSize = 64 << 10
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// newCSVReader decodes src to UTF-8 (Options.Encoding) as it is read and detects the delimiter
+// from the first decoded bytes unless Options.Delimiter is set
+func newCSVReader(src io.Reader, opts Options) (*csv.Reader, error) {
+	text, err := decodeReader(src, opts.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReaderSize(text, sniffSize)
+	r := csv.NewReader(br)
+	r.TrimLeadingSpace = true
+	r.Comma = opts.Delimiter
+	if r.Comma == 0 {
+		head, err := peek(br)
+		if err != nil {
+			return nil, err
+		}
+		r.Comma = detectDelimiter(head)
+	}
+	return r, nil
+}
+
+// peek returns up to sniffSize bytes of br without consuming them
+func peek(br *bufio.Reader) ([]byte, error) {
+	head, err := br.Peek(sniffSize)
+	if err == io.EOF {
+		err = nil
+	}
+	return head, err
+}
+
+// decodeReader returns src converted to UTF-8 without a BOM; EncodingAuto detects the
+// encoding from the first sniffSize bytes
+func decodeReader(src io.Reader, enc Encoding) (io.Reader, error) {
+	br := bufio.NewReaderSize(src, sniffSize)
+	head, err := peek(br)
+	if err != nil {
+		return nil, err
+	}
+	if enc == "" || enc == EncodingAuto {
+		enc = detectEncoding(head, len(head) < sniffSize)
+	}
+	switch enc {
+	case EncodingUTF8:
+		if bytes.HasPrefix(head, utf8BOM) {
+			br.Discard(len(utf8BOM))
+		}
+		var offset int64
+		return &runeReader{next: func() (rune, error) {
+			r, size, err := br.ReadRune()
+			if err == nil && r == utf8.RuneError && size == 1 {
+				return 0, fmt.Errorf("input is not valid UTF-8 at byte %d", offset)
+			}
+			offset += int64(size)
+			return r, err
+		}}, nil
+	case EncodingUTF16LE, EncodingUTF16BE:
+		bigEndian := enc == EncodingUTF16BE
+		if bytes.HasPrefix(head, utf16BOM(bigEndian)) {
+			br.Discard(2)
+		}
+		d := &utf16Decoder{br: br, bigEndian: bigEndian, pending: -1}
+		return &runeReader{next: d.next}, nil
+	case EncodingWindows1252:
+		return &runeReader{next: func() (rune, error) {
+			c, err := br.ReadByte()
+			switch {
+			case err != nil || c < 0x80 || c >= 0xA0:
+				return rune(c), err
+			default:
+				return windows1252[c-0x80], nil
+			}
+		}}, nil
+	default:
+		return nil, fmt.Errorf("unknown encoding: %s", enc)
+	}
+}
+
+// runeReader is an io.Reader of the UTF-8 encoding of the runes returned by next
+type runeReader struct {
+	next func() (rune, error)
+	buf  []byte
+	err  error
+}
+
+func (d *runeReader) Read(p []byte) (int, error) {
+	for len(d.buf) < len(p) && d.err == nil {
+		var r rune
+		if r, d.err = d.next(); d.err == nil {
+			d.buf = utf8.AppendRune(d.buf, r)
+		}
+	}
+	n := copy(p, d.buf)
+	d.buf = append(d.buf[:0], d.buf[n:]...)
+	if len(d.buf) == 0 && d.err != nil {
+		return n, d.err
+	}
+	return n, nil
+}
+
+// detectEncoding looks at the BOM first, then at the zero bytes of UTF-16 text and UTF-8 validity.
+// head is the start of the input; complete tells whether it is the whole input.
+func detectEncoding(head []byte, complete bool) Encoding {
+	switch {
+	case bytes.HasPrefix(head, utf8BOM):
+		return EncodingUTF8
+	case bytes.HasPrefix(head, utf16BOM(false)):
+		return EncodingUTF16LE
+	case bytes.HasPrefix(head, utf16BOM(true)):
+		return EncodingUTF16BE
+	}
+	// ASCII text in UTF-16 has a zero in every other byte
+	sample := head
+	if len(sample) > 1024 {
+		sample = sample[:1024]
+	}
+	var evenZero, oddZero int
+	for i, b := range sample {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			evenZero++
+		} else {
+			oddZero++
+		}
+	}
+	switch half := len(sample) / 4; {
+	case half > 0 && oddZero > half && evenZero == 0:
+		return EncodingUTF16LE
+	case half > 0 && evenZero > half && oddZero == 0:
+		return EncodingUTF16BE
+	case utf8.Valid(trimPartialRune(head, complete)):
+		return EncodingUTF8
+	default:
+		return EncodingWindows1252
+	}
+}
+
+// trimPartialRune drops a multi-byte sequence cut by the end of an incomplete sample
+func trimPartialRune(head []byte, complete bool) []byte {
+	if complete {
+		return head
+	}
+	i := len(head) - 1
+	for i > 0 && len(head)-i < utf8.UTFMax && !utf8.RuneStart(head[i]) {
+		i--
+	}
+	if i >= 0 && !utf8.FullRune(head[i:]) {
+		return head[:i]
+	}
+	return head
+}
+
+func utf16BOM(bigEndian bool) []byte {
+	if bigEndian {
+		return []byte{0xFE, 0xFF}
+	}
+	return []byte{0xFF, 0xFE}
+}
+
+// utf16Decoder reads UTF-16 runes, joining surrogate pairs; an unpaired surrogate gives U+FFFD
+type utf16Decoder struct {
+	br        *bufio.Reader
+	bigEndian bool
+	pending   rune // unit read after an unpaired high surrogate, or -1
+}
+
+func (d *utf16Decoder) unit() (rune, error) {
+	var b [2]byte
+	if _, err := io.ReadFull(d.br, b[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = fmt.Errorf("utf-16 input has an odd number of bytes")
+		}
+		return 0, err
+	}
+	if d.bigEndian {
+		return rune(b[0])<<8 | rune(b[1]), nil
+	}
+	return rune(b[1])<<8 | rune(b[0]), nil
+}
+
+func (d *utf16Decoder) next() (rune, error) {
+	r1 := d.pending
+	d.pending = -1
+	if r1 < 0 {
+		var err error
+		if r1, err = d.unit(); err != nil {
+			return 0, err
+		}
+	}
+	if !utf16.IsSurrogate(r1) {
+		return r1, nil
+	}
+	r2, err := d.unit()
+	if err == io.EOF {
+		return utf8.RuneError, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if r := utf16.DecodeRune(r1, r2); r != utf8.RuneError {
+		return r, nil
+	}
+	d.pending = r2
+	return utf8.RuneError, nil
+}
+
+// windows1252 maps 0x80-0x9F; the other bytes equal their Latin-1 code points
+var windows1252 = [32]rune{
+	'€', 0xFFFD, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0xFFFD, 'Ž', 0xFFFD,
+	0xFFFD, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0xFFFD, 'ž', 'Ÿ',
+}
+
+// detectDelimiter picks the candidate with the most lines sharing the same non-zero count
+// (quoted text ignored) among the first lines; ties go to the larger count, then to the order
+// of delimiters. Preamble lines with another shape therefore do not decide the result.
+func detectDelimiter(text []byte) rune {
+	var lines []string
+	for _, l := range strings.Split(string(text), "\n") {
+		if strings.TrimSpace(l) != "" {
+			lines = append(lines, l)
+		}
+		if len(lines) == 20 {
+			break
+		}
+	}
+	best, bestLines, bestCount := ',', 0, 0
+	for _, d := range delimiters {
+		freq := map[int]int{}
+		for _, l := range lines {
+			if n := countOutsideQuotes(l, d); n > 0 {
+				freq[n]++
+			}
+		}
+		for n, c := range freq {
+			if c > bestLines || (c == bestLines && n > bestCount) {
+				best, bestLines, bestCount = d, c, n
+			}
+		}
+	}
+	return best
+}
+
+func countOutsideQuotes(line string, d rune) int {
+	n := 0
+	quoted := false
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == d && !quoted:
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,276 @@
+package parser
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// Note: Comments in English per instruction
+
+// writeTree creates the given files (paths relative to a new temp dir) and returns the dir
+func writeTree(t *testing.T, files ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, f := range files {
+		p := filepath.Join(dir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestSplitBankSpec(t *testing.T) {
+	cases := []struct {
+		spec, name, path string
+	}{
+		{"bca=/data/bca/*.csv", "bca", "/data/bca/*.csv"},
+		{"bank_bni-2 = x.csv", "bank_bni-2", "x.csv"},
+		{"/data/run=1/bca.csv", "", "/data/run=1/bca.csv"},
+		{"=x.csv", "", "=x.csv"},
+		{"bca.csv", "", "bca.csv"},
+		{"-", "", "-"},
+	}
+	for _, c := range cases {
+		if name, p := SplitBankSpec(c.spec); name != c.name || p != c.path {
+			t.Fatalf("%q: got %q %q", c.spec, name, p)
+		}
+	}
+}
+
+func TestExpandBankSpec(t *testing.T) {
+	dir := writeTree(t, "bca_0102.csv", "bca_0101.csv", "bni.csv", "run=1/mandiri.csv")
+	join := func(p string) string { return filepath.Join(dir, filepath.FromSlash(p)) }
+
+	got, err := ExpandBankSpec("bca=" + join("bca_*.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []BankSource{{"bca", join("bca_0101.csv")}, {"bca", join("bca_0102.csv")}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("named glob: got %+v", got)
+	}
+
+	// "=" inside a path does not make a bank name
+	got, err = ExpandBankSpec(join("run=1/*.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []BankSource{{"", join("run=1/mandiri.csv")}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("path with '=': got %+v", got)
+	}
+
+	// a plain path is kept as is, even when it does not exist yet
+	if got, err := ExpandBankSpec("bni=" + join("missing.csv")); err != nil || len(got) != 1 || got[0].Name != "bni" {
+		t.Fatalf("plain path: got %+v %v", got, err)
+	}
+	if _, err := ExpandBankSpec("bca=" + join("*.xml")); err == nil {
+		t.Fatalf("expected an error for a glob without matches")
+	}
+}
+
+func TestExpandBankDir(t *testing.T) {
+	dir := writeTree(t,
+		"bca/0101.csv", "bca/0102.csv.gz", "bca/.0103.csv", "bca/notes.md",
+		"mandiri/stmt.xml", ".git/config.csv",
+		"bni.csv", ".hidden.csv", "readme.md")
+	join := func(p string) string { return filepath.Join(dir, filepath.FromSlash(p)) }
+
+	got, err := ExpandBankDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []BankSource{
+		{"bca", join("bca/0101.csv")},
+		{"bca", join("bca/0102.csv.gz")},
+		{"", join("bni.csv")},
+		{"mandiri", join("mandiri/stmt.xml")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("per-bank subdirectories: got %+v", got)
+	}
+
+	// a named directory reads its own files only, all as that bank
+	got, err = ExpandBankDir("bank_x=" + dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []BankSource{{"bank_x", join("bni.csv")}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("named dir: got %+v", got)
+	}
+
+	if _, err := ExpandBankDir(writeTree(t, "docs/readme.md", ".cache/a.csv")); err == nil {
+		t.Fatalf("expected an error for a directory without statement files")
+	}
+}
+
+// walkAll collects the name and content of every document WalkInputs gives
+func walkAll(t *testing.T, p string, stdin io.Reader) []Input {
+	t.Helper()
+	var out []Input
+	err := WalkInputs(p, stdin, func(in Input) error {
+		data, err := io.ReadAll(in)
+		out = append(out, Input{Name: in.Name, Reader: bytes.NewReader(data)})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("%s: %v", p, err)
+	}
+	return out
+}
+
+func content(t *testing.T, in Input) string {
+	t.Helper()
+	data, err := io.ReadAll(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestWalkInputs_Compressed(t *testing.T) {
+	const doc = "unique_identifier,amount,date\nB1,100,2024-01-02\n"
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	if _, err := zw.Write([]byte(doc)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	gzPath := writeFile(t, "bca.csv.gz", gz.String())
+
+	// bzip2 -c of doc; the standard library has no bzip2 writer
+	bz := []byte{
+		0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0x04, 0x56,
+		0xc5, 0x5f, 0x00, 0x00, 0x17, 0x5f, 0x80, 0x00, 0x10, 0x00, 0x06, 0x74,
+		0x00, 0x10, 0x00, 0x00, 0x00, 0xa7, 0x23, 0xb6, 0x00, 0x20, 0x00, 0x22,
+		0x29, 0xfa, 0xa6, 0xf4, 0x49, 0xe4, 0x8d, 0x0f, 0x4d, 0x0a, 0x06, 0x9a,
+		0x19, 0x19, 0x31, 0x30, 0xcc, 0xd3, 0xa7, 0x24, 0x3a, 0x34, 0x18, 0x00,
+		0x5a, 0x20, 0xf8, 0x3d, 0xc5, 0x1b, 0x26, 0x7e, 0x59, 0xfe, 0xe8, 0xf3,
+		0xd7, 0x2a, 0xa2, 0xbb, 0xad, 0x8b, 0xb9, 0x22, 0x9c, 0x28, 0x48, 0x02,
+		0x2b, 0x62, 0xaf, 0x80,
+	}
+	bzPath := writeFile(t, "bca.csv.bz2", string(bz))
+
+	for _, p := range []string{gzPath, bzPath} {
+		in := walkAll(t, p, nil)
+		if len(in) != 1 || in[0].Name != p || content(t, in[0]) != doc {
+			t.Fatalf("%s: unexpected inputs %+v", p, in)
+		}
+	}
+
+	var zbuf bytes.Buffer
+	arc := zip.NewWriter(&zbuf)
+	for _, name := range []string{"mandiri.csv", "__MACOSX/._bca.csv", "bca.csv", "sub/"} {
+		w, err := arc.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasSuffix(name, "/") {
+			continue
+		}
+		if _, err := w.Write([]byte(doc)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := arc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zipPath := writeFile(t, "daily.zip", zbuf.String())
+
+	// zip from a file uses random access; from stdin it is read into memory
+	for _, c := range []struct {
+		path  string
+		stdin io.Reader
+	}{{zipPath, nil}, {StdinName, bytes.NewReader(zbuf.Bytes())}} {
+		in := walkAll(t, c.path, c.stdin)
+		if len(in) != 2 || in[0].Name != c.path+"!/bca.csv" || in[1].Name != c.path+"!/mandiri.csv" {
+			t.Fatalf("unexpected zip entries: %+v", in)
+		}
+		bf, err := ReadBankStatementsFrom(in[0], in[0].Name, "bca", Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(bf.Rows) != 1 || bf.Rows[0].Source.Path != c.path+"!/bca.csv" || bf.Rows[0].Source.Line != 2 {
+			t.Fatalf("unexpected rows: %+v", bf.Rows)
+		}
+	}
+
+	// "-" streams the given stdin reader: nothing past what fn reads is consumed
+	stdin := io.MultiReader(strings.NewReader(doc), iotest.ErrReader(errors.New("read past the document")))
+	err := WalkInputs(StdinName, stdin, func(in Input) error {
+		if in.Name != StdinName {
+			t.Fatalf("unexpected stdin name %q", in.Name)
+		}
+		line, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil || line != "unique_identifier,amount,date\n" {
+			t.Fatalf("unexpected first line %q: %v", line, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDuplicateInputs(t *testing.T) {
+	hashOf := func(content string, readFirst int) string {
+		hr := NewHashReader(strings.NewReader(content))
+		// a parser may stop early; Sum still covers the whole content
+		if _, err := io.ReadFull(hr, make([]byte, readFirst)); err != nil {
+			t.Fatal(err)
+		}
+		h, err := hr.Sum()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	a := hashOf("a,b\n1,2\n", 0)
+	if a != hashOf("a,b\n1,2\n", 4) || a == hashOf("a,b\n1,3\n", 4) {
+		t.Fatalf("hash must cover the whole content")
+	}
+	sum := sha256.Sum256([]byte("a,b\n1,2\n"))
+	if a != hex.EncodeToString(sum[:]) {
+		t.Fatalf("got %s, want the SHA-256 of the content", a)
+	}
+
+	var dups DuplicateInputs
+	inputs := []struct{ name, hash string }{
+		{"bca_0101.csv", a},
+		{"bca_0102.csv", hashOf("b", 0)},
+		{"bca_0101.csv.gz", a},
+		{"copy.csv", a},
+	}
+	var got []DuplicateInput
+	for _, in := range inputs {
+		if d, ok := dups.Check(in.name, in.hash); ok {
+			got = append(got, d)
+		}
+	}
+	if len(got) != 2 || got[0].Name != "bca_0101.csv.gz" || got[1].Name != "copy.csv" {
+		t.Fatalf("unexpected duplicates: %+v", got)
+	}
+	for _, d := range got {
+		if d.SameAs != "bca_0101.csv" || d.Hash != a {
+			t.Fatalf("unexpected duplicate: %+v", d)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Note: Comments in English per instruction
+
+// canonicalFields are the column names the readers look up
+var canonicalFields = []string{
+	"trxID", "amount", "type", "transactionTime", "currency",
+	"unique_identifier", "date", "debit", "credit", "indicator",
+}
+
+// Profile maps the headers of a source file to canonical fields and carries its parsing settings.
+// Empty settings leave the corresponding Options field unchanged.
+type Profile struct {
+	// Columns maps a canonical field to the source headers that may hold it, tried in order
+	Columns      map[string][]string `json:"columns"`
+	Currency     string              `json:"currency,omitempty"`
+	NumberFormat NumberFormat        `json:"numberFormat,omitempty"`
+	Rounding     RoundingMode        `json:"rounding,omitempty"`
+	AmountLayout AmountLayout        `json:"amountLayout,omitempty"`
+	DateLayouts  []string            `json:"dateLayouts,omitempty"`
+	Encoding     Encoding            `json:"encoding,omitempty"`
+	// Delimiter is one of "," ";" "|" "tab"
+	Delimiter string `json:"delimiter,omitempty"`
+	// Attributes selects the extra columns kept on each row; empty keeps all
+	Attributes []string `json:"attributes,omitempty"`
+}
+
+// LoadProfiles reads a JSON file of named profiles:
+//
+//	{"profiles": {"bca": {"columns": {"unique_identifier": ["No. Referensi"], "amount": ["Nominal"]}, "numberFormat": "comma"}}}
+func LoadProfiles(path string) (map[string]Profile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg struct {
+		Profiles map[string]Profile `json:"profiles"`
+	}
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("parse profiles: %w", err)
+	}
+	for name, p := range cfg.Profiles {
+		if err := p.validate(); err != nil {
+			return nil, fmt.Errorf("profile %s: %w", name, err)
+		}
+	}
+	return cfg.Profiles, nil
+}
+
+func (p Profile) validate() error {
+	for field := range p.Columns {
+		if canonicalField(field) == "" {
+			return fmt.Errorf("unknown field: %s", field)
+		}
+	}
+	if _, err := ParseNumberFormat(string(p.NumberFormat)); err != nil {
+		return err
+	}
+	if _, err := ParseRoundingMode(string(p.Rounding)); err != nil {
+		return err
+	}
+	if _, err := ParseAmountLayout(string(p.AmountLayout)); err != nil {
+		return err
+	}
+	if _, err := ParseEncoding(string(p.Encoding)); err != nil {
+		return err
+	}
+	if _, err := ParseDelimiter(p.Delimiter); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Apply returns opts with the profile's columns and non-empty settings
+func (p Profile) Apply(opts Options) Options {
+	if len(p.Columns) > 0 {
+		opts.Columns = make(map[string][]string, len(p.Columns))
+		for field, aliases := range p.Columns {
+			opts.Columns[canonicalField(field)] = aliases
+		}
+	}
+	if p.Currency != "" {
+		opts.Currency = p.Currency
+	}
+	if p.NumberFormat != "" {
+		opts.NumberFormat = p.NumberFormat
+	}
+	if p.Rounding != "" {
+		opts.Rounding = p.Rounding
+	}
+	if p.AmountLayout != "" {
+		opts.AmountLayout = p.AmountLayout
+	}
+	if len(p.DateLayouts) > 0 {
+		opts.DateLayouts = p.DateLayouts
+	}
+	if p.Encoding != "" {
+		opts.Encoding, _ = ParseEncoding(string(p.Encoding))
+	}
+	if d, _ := ParseDelimiter(p.Delimiter); d != 0 {
+		opts.Delimiter = d
+	}
+	if len(p.Attributes) > 0 {
+		opts.Attributes = p.Attributes
+	}
+	return opts
+}
+
+// canonicalField returns the canonical spelling of a field name, or "" when unknown
+func canonicalField(name string) string {
+	for _, f := range canonicalFields {
+		if strings.EqualFold(f, strings.TrimSpace(name)) {
+			return f
+		}
+	}
+	return ""
+}
+
+// toIndex maps header -> column index. Canonical fields are also found case-insensitively,
+// either by their own name or by one of the aliases (tried in order, first match wins).
+func toIndex(headers []string, aliases map[string][]string) map[string]int {
+	idx := make(map[string]int, len(headers))
+	byFold := make(map[string]int, len(headers))
+	for i, h := range headers {
+		h = strings.TrimSpace(h)
+		idx[h] = i
+		if _, ok := byFold[strings.ToLower(h)]; !ok {
+			byFold[strings.ToLower(h)] = i
+		}
+	}
+	for _, f := range canonicalFields {
+		for _, name := range append(append([]string(nil), aliases[f]...), f) {
+			if i, ok := byFold[strings.ToLower(strings.TrimSpace(name))]; ok {
+				idx[f] = i
+				break
+			}
+		}
+	}
+	return idx
+}
@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"recon-service/internal/models"
+)
+
+// Note: Comments in English per instruction
+
+// StatementMeta is the statement information found in the preamble and trailer rows of a bank export.
+// Fields stay zero (balances nil) when the export does not carry them.
+type StatementMeta struct {
+	AccountNumber string
+	// Period is the raw period text; PeriodStart/PeriodEnd are set when it parses with the date layouts
+	Period              string
+	PeriodStart         time.Time
+	PeriodEnd           time.Time
+	Currency            string
+	OpeningBalanceMinor *int64
+	ClosingBalanceMinor *int64
+}
+
+// Metadata labels, compared case-insensitively against the start of a row's first cell
+const (
+	metaAccount = "account"
+	metaPeriod  = "period"
+	metaCurr    = "currency"
+	metaOpening = "opening"
+	metaClosing = "closing"
+	metaTotal   = "total"
+)
+
+var metaLabels = []struct {
+	prefix, key string
+}{
+	{"no. rekening", metaAccount}, {"nomor rekening", metaAccount}, {"no rekening", metaAccount},
+	{"account number", metaAccount}, {"account no", metaAccount},
+	{"periode", metaPeriod}, {"period", metaPeriod},
+	{"mata uang", metaCurr}, {"currency", metaCurr},
+	{"saldo awal", metaOpening}, {"opening balance", metaOpening},
+	{"saldo akhir", metaClosing}, {"closing balance", metaClosing},
+	{"mutasi debet", metaTotal}, {"mutasi kredit", metaTotal}, {"total", metaTotal}, {"jumlah", metaTotal},
+}
+
+// metaLabel returns the metadata key and value of a label/value row such as
+// "No. Rekening : 123" or ["Saldo Awal", ":", "1.000,00"]; ok is false for other rows.
+func metaLabel(rec []string) (key, value string, ok bool) {
+	var cells []string
+	for _, c := range rec {
+		if c = strings.TrimSpace(c); c != "" {
+			cells = append(cells, c)
+		}
+	}
+	if len(cells) == 0 {
+		return "", "", false
+	}
+	label := cells[0]
+	rest := cells[1:]
+	if i := strings.Index(label, ":"); i >= 0 {
+		rest = append([]string{label[i+1:]}, rest...)
+		label = label[:i]
+	}
+	lower := strings.ToLower(strings.TrimSpace(label))
+	for _, l := range metaLabels {
+		if strings.HasPrefix(lower, l.prefix) && !startsWithLetter(lower[len(l.prefix):]) {
+			for _, v := range rest {
+				if v = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(v), ":")); v != "" {
+					return l.key, v, true
+				}
+			}
+			return l.key, "", true
+		}
+	}
+	return "", "", false
+}
+
+// apply stores a metadata value; balances are parsed as bank amounts in the statement currency
+func (m *StatementMeta) apply(key, value string, opts Options) error {
+	switch key {
+	case metaAccount:
+		m.AccountNumber = value
+	case metaPeriod:
+		m.Period = value
+		m.PeriodStart, m.PeriodEnd = parsePeriod(value, opts.DateLayouts)
+	case metaCurr:
+		m.Currency = models.NormalizeCurrency(value)
+	case metaOpening, metaClosing:
+		if value == "" {
+			return nil
+		}
+		currency := opts.Currency
+		if m.Currency != "" {
+			currency = m.Currency
+		}
+		exp, err := models.CurrencyExponent(currency)
+		if err != nil {
+			return err
+		}
+		v, err := parseBankAmount(value, currency, exp, opts.NumberFormat, opts.Rounding)
+		if err != nil {
+			return fmt.Errorf("%s balance: %w", key, err)
+		}
+		if key == metaOpening {
+			m.OpeningBalanceMinor = &v
+		} else {
+			m.ClosingBalanceMinor = &v
+		}
+	}
+	return nil
+}
+
+// parsePeriod reads "01/01/2024 - 31/01/2024" or "2024-01-01 s/d 2024-01-31"; zero times when it does not parse
+func parsePeriod(s string, layouts []string) (time.Time, time.Time) {
+	for _, sep := range []string{" s/d ", " sd ", " to ", " - ", " – "} {
+		parts := strings.SplitN(s, sep, 2)
+		if len(parts) != 2 {
+			continue
+		}
+		start, err1 := parseBankDate(parts[0], layouts)
+		end, err2 := parseBankDate(parts[1], layouts)
+		if err1 == nil && err2 == nil {
+			return start, end
+		}
+	}
+	return time.Time{}, time.Time{}
+}
+
+func startsWithLetter(s string) bool {
+	for _, r := range s {
+		return unicode.IsLetter(r)
+	}
+	return false
+}
+
+// blankRecord reports whether every cell is empty
+func blankRecord(rec []string) bool {
+	for _, c := range rec {
+		if strings.TrimSpace(c) != "" {
+			return false
+		}
+	}
+	return true
+}
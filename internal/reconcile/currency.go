@@ -0,0 +1,128 @@
+package reconcile
+
+import (
+	"fmt"
+	"time"
+
+	"recon-service/internal/models"
+)
+
+// Note: Comments in English per instruction
+
+const (
+	// ReasonCurrencyMismatch marks a pair in different currencies that could not be converted
+	ReasonCurrencyMismatch = "currency_mismatch"
+	// ReasonFX marks a cross-currency difference within the FX tolerance
+	ReasonFX = "fx"
+)
+
+// CurrencyTotals are the summary totals restricted to one currency (minor units of that currency)
+type CurrencyTotals struct {
+	TotalProcessed         int   `json:"totalProcessed"`
+	TotalMatched           int   `json:"totalMatched"`
+	TotalUnmatched         int   `json:"totalUnmatched"`
+	TotalAmountDiscrepancy int64 `json:"totalAmountDiscrepancyMinor"`
+}
+
+type currencyTotals map[string]*CurrencyTotals
+
+func (c currencyTotals) get(code string) *CurrencyTotals {
+	code = models.NormalizeCurrency(code)
+	t, ok := c[code]
+	if !ok {
+		t = &CurrencyTotals{}
+		c[code] = t
+	}
+	return t
+}
+
+func (c currencyTotals) values() map[string]CurrencyTotals {
+	out := make(map[string]CurrencyTotals, len(c))
+	for k, v := range c {
+		out[k] = *v
+	}
+	return out
+}
+
+func sameCurrency(a, b string) bool {
+	return models.NormalizeCurrency(a) == models.NormalizeCurrency(b)
+}
+
+func (o Options) reportingCurrency() string {
+	return models.NormalizeCurrency(o.ReportingCurrency)
+}
+
+// toReporting converts both signed amounts into the reporting currency at the bank date
+func (o Options) toReporting(s models.SystemTransaction, r models.BankStatement) (int64, int64, error) {
+	if o.FX == nil {
+		return 0, 0, fmt.Errorf("no fx table")
+	}
+	to := o.reportingCurrency()
+	sysSigned, err := s.Type.SignedAmount(s.AmountMinor)
+	if err != nil {
+		return 0, 0, err
+	}
+	sysConv, err := o.FX.Convert(sysSigned, s.Currency, to, r.Date)
+	if err != nil {
+		return 0, 0, err
+	}
+	bankConv, err := o.FX.Convert(r.AmountMinor, r.Currency, to, r.Date)
+	if err != nil {
+		return 0, 0, err
+	}
+	return sysConv, bankConv, nil
+}
+
+// discrepancyTotal sums discrepancies for the top-level total. Minor units of different
+// currencies cannot be added, so with several currencies the total is converted into the
+// reporting currency (Options.FX) or left out.
+type discrepancyTotal struct {
+	currencies  map[string]bool
+	sum         int64 // valid with a single currency
+	reporting   int64 // in the reporting currency, valid while convertible
+	convertible bool
+}
+
+func newDiscrepancyTotal() *discrepancyTotal {
+	return &discrepancyTotal{currencies: map[string]bool{}, convertible: true}
+}
+
+func (t *discrepancyTotal) add(diff int64, currency string, date time.Time, o Options) {
+	currency = models.NormalizeCurrency(currency)
+	t.currencies[currency] = true
+	t.sum += diff
+	if !t.convertible {
+		return
+	}
+	if currency == o.reportingCurrency() {
+		t.reporting += diff
+		return
+	}
+	if o.FX == nil {
+		t.convertible = false
+		return
+	}
+	v, err := o.FX.Convert(diff, currency, o.reportingCurrency(), date)
+	if err != nil {
+		t.convertible = false
+		return
+	}
+	t.reporting += v
+}
+
+// result returns the total and its currency; ok is false when the currencies could not be combined
+func (t *discrepancyTotal) result(o Options) (total int64, currency string, ok bool) {
+	switch {
+	case len(t.currencies) == 0:
+		return 0, "", true
+	case len(t.currencies) == 1:
+		for c := range t.currencies {
+			currency = c
+		}
+		return t.sum, currency, true
+	case t.convertible:
+		return t.reporting, o.reportingCurrency(), true
+	default:
+		return 0, "", false
+	}
+}
@@ -0,0 +1,206 @@
+package reconcile
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"recon-service/internal/models"
+	"recon-service/internal/parser"
+)
+
+// Note: Comments in English per instruction
+
+// DuplicatePolicy decides which row represents a trxID that occurs more than once in the system data
+type DuplicatePolicy string
+
+const (
+	DuplicateFirst  DuplicatePolicy = "first"  // keep the first occurrence
+	DuplicateLast   DuplicatePolicy = "last"   // keep the last occurrence (default)
+	DuplicateSum    DuplicatePolicy = "sum"    // merge occurrences into one row with the summed signed amount
+	DuplicateReject DuplicatePolicy = "reject" // exclude the trxID from matching
+)
+
+// ParseDuplicatePolicy validates a policy name; empty means DuplicateLast
+func ParseDuplicatePolicy(s string) (DuplicatePolicy, error) {
+	switch p := DuplicatePolicy(s); p {
+	case "":
+		return DuplicateLast, nil
+	case DuplicateFirst, DuplicateLast, DuplicateSum, DuplicateReject:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown duplicate policy: %s", s)
+	}
+}
+
+// DuplicateSystem lists every occurrence of a repeated trxID and how it was resolved
+type DuplicateSystem struct {
+	TrxID       string             `json:"trxID"`
+	Policy      DuplicatePolicy    `json:"policy"`
+	Occurrences []SystemOccurrence `json:"occurrences"`
+}
+
+type SystemOccurrence struct {
+	AmountMinor     int64     `json:"amountMinor"`
+	Type            string    `json:"type"`
+	TransactionTime time.Time `json:"transactionTime"`
+}
+
+// dedupeSystem returns one row per trxID according to policy, plus the duplicates found.
+// Rejected trxIDs are left out of the returned rows.
+func dedupeSystem(txns []models.SystemTransaction, policy DuplicatePolicy) ([]models.SystemTransaction, []DuplicateSystem) {
+	if policy == "" {
+		policy = DuplicateLast
+	}
+	byID := map[string][]models.SystemTransaction{}
+	var order []string
+	for _, s := range txns {
+		if _, ok := byID[s.TrxID]; !ok {
+			order = append(order, s.TrxID)
+		}
+		byID[s.TrxID] = append(byID[s.TrxID], s)
+	}
+
+	var out []models.SystemTransaction
+	var dups []DuplicateSystem
+	for _, id := range order {
+		rows := byID[id]
+		if len(rows) == 1 {
+			out = append(out, rows[0])
+			continue
+		}
+		d := DuplicateSystem{TrxID: id, Policy: policy}
+		for _, r := range rows {
+			d.Occurrences = append(d.Occurrences, SystemOccurrence{
+				AmountMinor:     r.AmountMinor,
+				Type:            string(r.Type),
+				TransactionTime: r.TransactionTime,
+			})
+		}
+		dups = append(dups, d)
+
+		switch policy {
+		case DuplicateFirst:
+			out = append(out, rows[0])
+		case DuplicateLast:
+			out = append(out, rows[len(rows)-1])
+		case DuplicateSum:
+			out = append(out, sumSystemRows(rows))
+		case DuplicateReject:
+			// nothing kept for matching
+		}
+	}
+	sort.Slice(dups, func(i, j int) bool { return dups[i].TrxID < dups[j].TrxID })
+	return out, dups
+}
+
+// sumSystemRows merges rows into one with the summed signed amount and the first row's time
+func sumSystemRows(rows []models.SystemTransaction) models.SystemTransaction {
+	var total int64
+	for _, r := range rows {
+		signed, _ := r.Type.SignedAmount(r.AmountMinor)
+		total += signed
+	}
+	merged := rows[0]
+	merged.Type = models.TypeCredit
+	if total < 0 {
+		merged.Type = models.TypeDebit
+	}
+	merged.AmountMinor = abs64(total)
+	return merged
+}
+
+// DuplicateBank is a bank row whose UniqueIdentifier was already taken by another row
+type DuplicateBank struct {
+	UniqueIdentifier string    `json:"unique_identifier"`
+	BankName         string    `json:"bank"`
+	AmountMinor      int64     `json:"amountMinor"`
+	Date             time.Time `json:"date"`
+}
+
+// dedupeBank keeps one bank row per non-empty UniqueIdentifier across all files. When the system has
+// that ID, the row in its currency closest in amount (then date) to it is kept; otherwise the
+// first in-period row is kept. In-period rows win ties.
+// Every other in-period row is returned as a duplicate entry.
+func dedupeBank(files []*parser.BankFile, sysRows []models.SystemTransaction) ([]models.BankStatement, []DuplicateBank) {
+	sysByID := make(map[string]models.SystemTransaction, len(sysRows))
+	for _, s := range sysRows {
+		sysByID[s.TrxID] = s
+	}
+	byID := map[string][]models.BankStatement{}
+	var order []string
+	var blank []models.BankStatement
+	for _, bf := range files {
+		for _, r := range bf.Rows {
+			// rows without an identifier cannot repeat one another
+			if r.UniqueIdentifier == "" {
+				blank = append(blank, r)
+				continue
+			}
+			if _, ok := byID[r.UniqueIdentifier]; !ok {
+				order = append(order, r.UniqueIdentifier)
+			}
+			byID[r.UniqueIdentifier] = append(byID[r.UniqueIdentifier], r)
+		}
+	}
+
+	var out []models.BankStatement
+	var dups []DuplicateBank
+	for _, id := range order {
+		rows := byID[id]
+		keep := firstInPeriod(rows)
+		if s, ok := sysByID[id]; ok && len(rows) > 1 {
+			signed, _ := s.Type.SignedAmount(s.AmountMinor)
+			best := -1
+			for k, r := range rows {
+				// amounts in another currency are not comparable
+				if !sameCurrency(s.Currency, r.Currency) {
+					continue
+				}
+				if best < 0 {
+					best = k
+					continue
+				}
+				dk, dbest := abs64(signed-r.AmountMinor), abs64(signed-rows[best].AmountMinor)
+				gk, gbest := dayGap(s.DateFor(r.BankName), r.Date), dayGap(s.DateFor(rows[best].BankName), rows[best].Date)
+				if dk < dbest || (dk == dbest && (gk < gbest || (gk == gbest && !r.OutOfPeriod && rows[best].OutOfPeriod))) {
+					best = k
+				}
+			}
+			if best >= 0 {
+				keep = best
+			}
+		}
+		out = append(out, rows[keep])
+		for k, r := range rows {
+			// buffer rows belong to another period and are not reported
+			if k == keep || r.OutOfPeriod {
+				continue
+			}
+			dups = append(dups, DuplicateBank{
+				UniqueIdentifier: r.UniqueIdentifier,
+				BankName:         r.BankName,
+				AmountMinor:      r.AmountMinor,
+				Date:             r.Date,
+			})
+		}
+	}
+	out = append(out, blank...)
+	sort.SliceStable(dups, func(i, j int) bool {
+		if dups[i].UniqueIdentifier != dups[j].UniqueIdentifier {
+			return dups[i].UniqueIdentifier < dups[j].UniqueIdentifier
+		}
+		return dups[i].BankName < dups[j].BankName
+	})
+	return out, dups
+}
+
+// firstInPeriod returns the index of the first row that is not a buffer row, or 0
+func firstInPeriod(rows []models.BankStatement) int {
+	for k, r := range rows {
+		if !r.OutOfPeriod {
+			return k
+		}
+	}
+	return 0
+}
@@ -0,0 +1,286 @@
+package reconcile
+
+import (
+	"sort"
+	"time"
+
+	"recon-service/internal/models"
+)
+
+// Note: Comments in English per instruction
+
+// Group kinds
+const (
+	GroupManyToOne = "manyToOne" // several system rows settled as one bank row
+	GroupOneToMany = "oneToMany" // one system row split across several bank rows
+)
+
+// GroupOptions configures split/batched settlement matching. Zero limits use the defaults below.
+type GroupOptions struct {
+	Enabled bool
+	// DayWindow is the max distance in days between the single row and each group member
+	DayWindow int
+	// MaxGroupSize is the max number of members in a group (default 5)
+	MaxGroupSize int
+	// MaxCandidates caps how many closest rows are considered per search (default 20)
+	MaxCandidates int
+	// MaxSteps caps the subset search per row (default 100000)
+	MaxSteps int
+}
+
+// GroupMatch is a many-to-one or one-to-many match whose signed totals are equal (same currency)
+type GroupMatch struct {
+	Kind        string   `json:"kind"`
+	TrxIDs      []string `json:"trxIDs"`
+	BankIDs     []string `json:"bankIDs"`
+	BankName    string   `json:"bank"`
+	AmountMinor int64    `json:"amountMinor"` // signed total
+	Currency    string   `json:"currency"`
+	// CrossesPeriod is set when some rows come from the settlement buffer
+	CrossesPeriod bool `json:"crossesPeriodBoundary,omitempty"`
+}
+
+func (o GroupOptions) withDefaults() GroupOptions {
+	if o.MaxGroupSize <= 0 {
+		o.MaxGroupSize = 5
+	}
+	if o.MaxCandidates <= 0 {
+		o.MaxCandidates = 20
+	}
+	if o.MaxSteps <= 0 {
+		o.MaxSteps = 100000
+	}
+	return o
+}
+
+// matchGroups finds many-to-one groups first (batched settlements), then one-to-many (splits).
+// It returns the groups plus the rows that are still unmatched.
+func matchGroups(sys []models.SystemTransaction, bank []models.BankStatement, opts GroupOptions) ([]GroupMatch, []models.SystemTransaction, []models.BankStatement) {
+	opts = opts.withDefaults()
+	sysUsed := make([]bool, len(sys))
+	bankUsed := make([]bool, len(bank))
+	sysSigned := make([]int64, len(sys))
+	for i, s := range sys {
+		sysSigned[i], _ = s.Type.SignedAmount(s.AmountMinor)
+	}
+
+	// Rows are bucketed by bank, currency, sign and day so each search only visits the
+	// days inside DayWindow. A system row's day depends on the bank (DateFor).
+	bankNames := map[string]bool{}
+	bankIdx := newDayIndex(func(j int) string { return bank[j].UniqueIdentifier })
+	for j, r := range bank {
+		bankNames[r.BankName] = true
+		bankIdx.add(groupKey{r.BankName, models.NormalizeCurrency(r.Currency), r.AmountMinor < 0, dayNumber(r.Date)}, j)
+	}
+	banks := make([]string, 0, len(bankNames))
+	for name := range bankNames {
+		banks = append(banks, name)
+	}
+	sort.Strings(banks)
+	sysIdx := newDayIndex(func(i int) string { return sys[i].TrxID })
+	sysDays := map[string][]int{}
+	for _, name := range banks {
+		days := make([]int, len(sys))
+		for i, s := range sys {
+			days[i] = dayNumber(s.DateFor(name))
+			sysIdx.add(groupKey{name, models.NormalizeCurrency(s.Currency), sysSigned[i] < 0, days[i]}, i)
+		}
+		sysDays[name] = days
+	}
+	bankIdx.sort()
+	sysIdx.sort()
+	var out []GroupMatch
+
+	// many system rows -> one bank row
+	for j, r := range bank {
+		k := groupKey{r.BankName, models.NormalizeCurrency(r.Currency), r.AmountMinor < 0, dayNumber(r.Date)}
+		cands := sysIdx.nearest(k, opts.DayWindow, opts.MaxCandidates, func(i int) bool {
+			return !sysUsed[i] && sameSignSmaller(sysSigned[i], r.AmountMinor)
+		})
+		members := findSubset(cands, func(i int) int64 { return abs64(sysSigned[i]) }, abs64(r.AmountMinor), opts)
+		if members == nil {
+			continue
+		}
+		g := GroupMatch{Kind: GroupManyToOne, BankIDs: []string{r.UniqueIdentifier}, BankName: r.BankName, AmountMinor: r.AmountMinor, Currency: models.NormalizeCurrency(r.Currency)}
+		flags := []bool{r.OutOfPeriod}
+		for _, i := range members {
+			sysUsed[i] = true
+			g.TrxIDs = append(g.TrxIDs, sys[i].TrxID)
+			flags = append(flags, sys[i].OutOfPeriod)
+		}
+		bankUsed[j] = true
+		out = appendGroup(out, g, flags)
+	}
+
+	// one system row -> many bank rows of the same bank
+	for i, s := range sys {
+		if sysUsed[i] {
+			continue
+		}
+		for _, name := range banks {
+			k := groupKey{name, models.NormalizeCurrency(s.Currency), sysSigned[i] < 0, sysDays[name][i]}
+			cands := bankIdx.nearest(k, opts.DayWindow, opts.MaxCandidates, func(j int) bool {
+				return !bankUsed[j] && sameSignSmaller(bank[j].AmountMinor, sysSigned[i])
+			})
+			members := findSubset(cands, func(j int) int64 { return abs64(bank[j].AmountMinor) }, abs64(sysSigned[i]), opts)
+			if members == nil {
+				continue
+			}
+			g := GroupMatch{Kind: GroupOneToMany, TrxIDs: []string{s.TrxID}, BankName: name, AmountMinor: sysSigned[i], Currency: models.NormalizeCurrency(s.Currency)}
+			flags := []bool{s.OutOfPeriod}
+			for _, j := range members {
+				bankUsed[j] = true
+				g.BankIDs = append(g.BankIDs, bank[j].UniqueIdentifier)
+				flags = append(flags, bank[j].OutOfPeriod)
+			}
+			sysUsed[i] = true
+			out = appendGroup(out, g, flags)
+			break
+		}
+	}
+
+	for k := range out {
+		sort.Strings(out[k].TrxIDs)
+		sort.Strings(out[k].BankIDs)
+	}
+	return out, keepUnused(sys, sysUsed), keepUnused(bank, bankUsed)
+}
+
+// appendGroup adds g unless every row is out of period; outOfPeriod holds one flag per row
+func appendGroup(out []GroupMatch, g GroupMatch, outOfPeriod []bool) []GroupMatch {
+	inPeriod := false
+	for _, o := range outOfPeriod {
+		if o {
+			g.CrossesPeriod = true
+		} else {
+			inPeriod = true
+		}
+	}
+	if !inPeriod {
+		return out
+	}
+	return append(out, g)
+}
+
+// sameSignSmaller reports whether part is non-zero, has the sign of total and a smaller magnitude
+func sameSignSmaller(part, total int64) bool {
+	if part == 0 || (part < 0) != (total < 0) {
+		return false
+	}
+	return abs64(part) < abs64(total)
+}
+
+// groupKey is a bucket of the group search; for system rows the day is the one seen by bank
+type groupKey struct {
+	bank     string
+	currency string
+	negative bool
+	day      int
+}
+
+// dayIndex holds row indices per groupKey, each bucket ordered by ID
+type dayIndex struct {
+	buckets map[groupKey][]int
+	id      func(int) string
+}
+
+func newDayIndex(id func(int) string) *dayIndex {
+	return &dayIndex{buckets: map[groupKey][]int{}, id: id}
+}
+
+func (x *dayIndex) add(k groupKey, i int) {
+	x.buckets[k] = append(x.buckets[k], i)
+}
+
+func (x *dayIndex) sort() {
+	for _, b := range x.buckets {
+		sort.SliceStable(b, func(a, c int) bool { return x.id(b[a]) < x.id(b[c]) })
+	}
+}
+
+// nearest returns at most limit rows that pass keep and lie at most window days from k.day,
+// closest days first and by ID within the same gap
+func (x *dayIndex) nearest(k groupKey, window, limit int, keep func(int) bool) []int {
+	var out []int
+	for gap := 0; gap <= window && len(out) < limit; gap++ {
+		need := limit - len(out)
+		before, after := k, k
+		before.day -= gap
+		after.day += gap
+		found := x.take(before, need, keep)
+		if gap > 0 {
+			found = append(found, x.take(after, need, keep)...)
+			sort.SliceStable(found, func(a, b int) bool { return x.id(found[a]) < x.id(found[b]) })
+		}
+		if len(found) > need {
+			found = found[:need]
+		}
+		out = append(out, found...)
+	}
+	return out
+}
+
+// take returns the first n rows of a bucket that pass keep
+func (x *dayIndex) take(k groupKey, n int, keep func(int) bool) []int {
+	var out []int
+	for _, i := range x.buckets[k] {
+		if len(out) == n {
+			break
+		}
+		if keep(i) {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// dayNumber counts calendar days since 1970-01-01
+func dayNumber(t time.Time) int {
+	return int(dateOnly(t).Unix() / 86400)
+}
+
+// findSubset looks for 2..MaxGroupSize candidates whose amounts (all positive) sum to target.
+// Smaller groups are tried first; the search gives up after MaxSteps visited nodes.
+func findSubset(cands []int, amount func(int) int64, target int64, opts GroupOptions) []int {
+	if len(cands) < 2 {
+		return nil
+	}
+	// Largest amounts first so partial sums overshoot early and get pruned
+	sorted := append([]int(nil), cands...)
+	sort.SliceStable(sorted, func(a, b int) bool { return amount(sorted[a]) > amount(sorted[b]) })
+
+	steps := 0
+	var pick []int
+	var dfs func(start, size int, sum int64) bool
+	dfs = func(start, size int, sum int64) bool {
+		if len(pick) == size {
+			return sum == target
+		}
+		for k := start; k < len(sorted); k++ {
+			steps++
+			if steps > opts.MaxSteps {
+				return false
+			}
+			v := amount(sorted[k])
+			if sum+v > target {
+				continue
+			}
+			pick = append(pick, sorted[k])
+			if dfs(k+1, size, sum+v) {
+				return true
+			}
+			pick = pick[:len(pick)-1]
+		}
+		return false
+	}
+	for size := 2; size <= opts.MaxGroupSize && size <= len(sorted); size++ {
+		pick = pick[:0]
+		if dfs(0, size, 0) {
+			return append([]int(nil), pick...)
+		}
+		if steps > opts.MaxSteps {
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package reconcile
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"recon-service/internal/models"
+)
+
+// Note: Comments in English per instruction
+
+// AmountDateMatcher pairs rows whose signed amounts and currencies are equal and whose dates are at
+// most DayWindow days apart. Closest dates are paired first; ties are broken by IDs
+// so the result does not depend on input order.
+type AmountDateMatcher struct {
+	DayWindow int
+}
+
+func (AmountDateMatcher) Name() string { return StrategyAmountDate }
+
+func (m AmountDateMatcher) Match(sys []models.SystemTransaction, bank []models.BankStatement) []Pair {
+	window := m.DayWindow
+	if window < 0 || len(sys) == 0 || len(bank) == 0 {
+		return nil
+	}
+
+	// amounts are only comparable within one currency
+	type amountKey struct {
+		currency string
+		amount   int64
+	}
+	bankByAmount := map[amountKey][]int{}
+	for j, r := range bank {
+		k := amountKey{models.NormalizeCurrency(r.Currency), r.AmountMinor}
+		bankByAmount[k] = append(bankByAmount[k], j)
+	}
+
+	type candidate struct {
+		si, bj int
+		gap    int
+	}
+	var cands []candidate
+	sysCount := make([]int, len(sys))
+	bankCount := make([]int, len(bank))
+	for i, s := range sys {
+		signed, err := s.Type.SignedAmount(s.AmountMinor)
+		if err != nil {
+			continue
+		}
+		for _, j := range bankByAmount[amountKey{models.NormalizeCurrency(s.Currency), signed}] {
+			gap := dayGap(s.DateFor(bank[j].BankName), bank[j].Date)
+			if gap > window {
+				continue
+			}
+			cands = append(cands, candidate{si: i, bj: j, gap: gap})
+			sysCount[i]++
+			bankCount[j]++
+		}
+	}
+	sort.Slice(cands, func(a, b int) bool {
+		ca, cb := cands[a], cands[b]
+		if ca.gap != cb.gap {
+			return ca.gap < cb.gap
+		}
+		sa, sb := sys[ca.si], sys[cb.si]
+		if sa.TrxID != sb.TrxID {
+			return sa.TrxID < sb.TrxID
+		}
+		ba, bb := bank[ca.bj], bank[cb.bj]
+		if ba.BankName != bb.BankName {
+			return ba.BankName < bb.BankName
+		}
+		return ba.UniqueIdentifier < bb.UniqueIdentifier
+	})
+
+	sysUsed := make([]bool, len(sys))
+	bankUsed := make([]bool, len(bank))
+	var out []Pair
+	for _, c := range cands {
+		if sysUsed[c.si] || bankUsed[c.bj] {
+			continue
+		}
+		sysUsed[c.si] = true
+		bankUsed[c.bj] = true
+		out = append(out, Pair{
+			SystemIndex: c.si,
+			BankIndex:   c.bj,
+			Confidence:  heuristicConfidence(c.gap, window, sysCount[c.si]+bankCount[c.bj]-1),
+			Heuristic:   true,
+		})
+	}
+	return out
+}
+
+// heuristicConfidence scores a match in (0,1]:
+// date proximity gives 1.0 on the same day down to just above 0.5 at the window edge,
+// and the score is divided by the number of competing candidates for either row.
+func heuristicConfidence(gap, window, competitors int) float64 {
+	proximity := 1 - float64(gap)/float64(2*(window+1))
+	if competitors < 1 {
+		competitors = 1
+	}
+	return math.Round(proximity/float64(competitors)*100) / 100
+}
+
+// dayGap returns the absolute number of calendar days between two times
+func dayGap(a, b time.Time) int {
+	d := dateOnly(a).Sub(dateOnly(b))
+	days := int(d.Hours() / 24)
+	if days < 0 {
+		return -days
+	}
+	return days
+}
+
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,186 @@
+package reconcile
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"recon-service/internal/models"
+)
+
+// Note: Comments in English per instruction
+
+// Strategy names of the built-in matchers
+const (
+	StrategyExactID    = "exact"
+	StrategyNormalized = "normalized"
+	StrategyReference  = "reference"
+	StrategyAmountDate = "amountdate"
+)
+
+// Pair links one system row to one bank row by their indices in the slices given to Matcher.Match
+type Pair struct {
+	SystemIndex int
+	BankIndex   int
+	// Confidence in (0,1]; ID-based strategies report 1
+	Confidence float64
+	// Heuristic marks pairs not backed by an identifier (reported under matchedByHeuristic)
+	Heuristic bool
+}
+
+// Matcher pairs rows that are still unmatched. The engine runs matchers in order and
+// removes the paired rows before calling the next one. A row index must not be returned twice.
+type Matcher interface {
+	Name() string
+	Match(sys []models.SystemTransaction, bank []models.BankStatement) []Pair
+}
+
+// DefaultMatchers returns the baseline chain: exact ID only. The fuzzier
+// normalized and reference strategies are opt-in.
+func DefaultMatchers() []Matcher {
+	return []Matcher{ExactIDMatcher{}}
+}
+
+// MatchersByName builds a chain from strategy names, e.g. "exact,normalized,reference,amountdate".
+// dayWindow configures the amountdate matcher.
+func MatchersByName(names []string, dayWindow int) ([]Matcher, error) {
+	var out []Matcher
+	for _, n := range names {
+		switch strings.ToLower(strings.TrimSpace(n)) {
+		case StrategyExactID:
+			out = append(out, ExactIDMatcher{})
+		case StrategyNormalized:
+			out = append(out, NormalizedIDMatcher{})
+		case StrategyReference:
+			out = append(out, ReferenceMatcher{})
+		case StrategyAmountDate:
+			out = append(out, AmountDateMatcher{DayWindow: dayWindow})
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown matcher: %s", n)
+		}
+	}
+	return out, nil
+}
+
+// ExactIDMatcher pairs rows where TrxID equals UniqueIdentifier
+type ExactIDMatcher struct{}
+
+func (ExactIDMatcher) Name() string { return StrategyExactID }
+
+func (ExactIDMatcher) Match(sys []models.SystemTransaction, bank []models.BankStatement) []Pair {
+	return matchByKey(sys, bank, func(s string) string { return s })
+}
+
+// NormalizedIDMatcher pairs rows whose IDs are equal ignoring case and punctuation,
+// e.g. "tx-001" and "TX 001". Keys shared by several rows on one side are skipped.
+type NormalizedIDMatcher struct{}
+
+func (NormalizedIDMatcher) Name() string { return StrategyNormalized }
+
+func (NormalizedIDMatcher) Match(sys []models.SystemTransaction, bank []models.BankStatement) []Pair {
+	return matchByKey(sys, bank, normalizeID)
+}
+
+// ReferenceMatcher extracts a system trxID embedded in a longer bank reference,
+// e.g. "TRF/TX-001/BCA". Tokens are compared in normalized form. Short IDs such as "0301"
+// would match date or sequence segments, so a candidate must be at least MinKeyLength
+// characters long and both rows must have the same signed amount and currency.
+type ReferenceMatcher struct {
+	// MaxTokens limits how many adjacent reference tokens are joined into a candidate (default 4)
+	MaxTokens int
+	// MinKeyLength is the minimum normalized trxID length considered (default 5)
+	MinKeyLength int
+}
+
+func (ReferenceMatcher) Name() string { return StrategyReference }
+
+func (m ReferenceMatcher) Match(sys []models.SystemTransaction, bank []models.BankStatement) []Pair {
+	maxTokens := m.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = 4
+	}
+	minKey := m.MinKeyLength
+	if minKey <= 0 {
+		minKey = 5
+	}
+	sysByKey := uniqueIndex(len(sys), func(i int) string { return normalizeID(sys[i].TrxID) })
+
+	used := map[int]bool{}
+	var out []Pair
+	for j, r := range bank {
+		tokens := splitTokens(r.UniqueIdentifier)
+		found := -1
+		ambiguous := false
+		for a := 0; a < len(tokens) && !ambiguous; a++ {
+			key := ""
+			for b := a; b < len(tokens) && b-a < maxTokens; b++ {
+				key += tokens[b]
+				i, ok := sysByKey[key]
+				if !ok || i == found || len(key) < minKey || !sameSignedAmount(sys[i], r) {
+					continue
+				}
+				if found >= 0 {
+					ambiguous = true
+					break
+				}
+				found = i
+			}
+		}
+		if ambiguous || found < 0 || used[found] {
+			continue
+		}
+		used[found] = true
+		out = append(out, Pair{SystemIndex: found, BankIndex: j, Confidence: 1})
+	}
+	return out
+}
+
+// sameSignedAmount reports whether both rows carry the same signed amount in the same currency
+func sameSignedAmount(s models.SystemTransaction, r models.BankStatement) bool {
+	signed, err := s.Type.SignedAmount(s.AmountMinor)
+	return err == nil && signed == r.AmountMinor && sameCurrency(s.Currency, r.Currency)
+}
+
+// matchByKey pairs rows whose keys are equal and unique on both sides
+func matchByKey(sys []models.SystemTransaction, bank []models.BankStatement, key func(string) string) []Pair {
+	sysByKey := uniqueIndex(len(sys), func(i int) string { return key(sys[i].TrxID) })
+	bankByKey := uniqueIndex(len(bank), func(j int) string { return key(bank[j].UniqueIdentifier) })
+	var out []Pair
+	for k, i := range sysByKey {
+		if j, ok := bankByKey[k]; ok {
+			out = append(out, Pair{SystemIndex: i, BankIndex: j, Confidence: 1})
+		}
+	}
+	return out
+}
+
+// uniqueIndex maps key -> index, leaving out empty keys and keys seen more than once
+func uniqueIndex(n int, key func(int) string) map[string]int {
+	idx := make(map[string]int, n)
+	dup := map[string]bool{}
+	for i := 0; i < n; i++ {
+		k := key(i)
+		if k == "" || dup[k] {
+			continue
+		}
+		if _, ok := idx[k]; ok {
+			delete(idx, k)
+			dup[k] = true
+			continue
+		}
+		idx[k] = i
+	}
+	return idx
+}
+
+// normalizeID upper-cases and keeps letters and digits only
+func normalizeID(s string) string {
+	return strings.Join(splitTokens(s), "")
+}
+
+func splitTokens(s string) []string {
+	return strings.FieldsFunc(strings.ToUpper(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
+	"recon-service/internal/fx"
 	"recon-service/internal/models"
 	"recon-service/internal/parser"
 )
@@ -12,108 +14,387 @@ import (
 // Note: Comments in English per instruction
 
 type UnmatchedSystem struct {
-	TrxID       string `json:"trxID"`
-	AmountMinor int64  `json:"amountMinor"`
-	Type        string `json:"type"`
+	TrxID       string        `json:"trxID"`
+	AmountMinor int64         `json:"amountMinor"`
+	Currency    string        `json:"currency"`
+	Type        string        `json:"type"`
+	Source      models.Source `json:"source"`
+	// Attributes holds the extra columns chosen by Options.ReportAttributes
+	Attributes map[string]string `json:"attributes,omitempty"`
 }
 
 type UnmatchedBank struct {
-	UniqueIdentifier string `json:"unique_identifier"`
-	AmountMinor      int64  `json:"amountMinor"`
-	BankName         string `json:"bank"`
+	UniqueIdentifier string            `json:"unique_identifier"`
+	AmountMinor      int64             `json:"amountMinor"`
+	Currency         string            `json:"currency"`
+	BankName         string            `json:"bank"`
+	Source           models.Source     `json:"source"`
+	Attributes       map[string]string `json:"attributes,omitempty"`
 }
 
 type MatchedDiff struct {
 	ID                string `json:"id"`
+	UniqueIdentifier  string `json:"unique_identifier,omitempty"` // set when the bank ID differs from ID
 	SystemAmountMinor int64  `json:"systemAmountMinor"`
 	BankAmountMinor   int64  `json:"bankAmountMinor"`
 	AbsDiffMinor      int64  `json:"absDiffMinor"`
-	BankName          string `json:"bank"`
+	SystemCurrency    string `json:"systemCurrency"`
+	BankCurrency      string `json:"bankCurrency"`
+	// Set when the currencies differ and amounts were compared after FX conversion
+	ReportingCurrency    string            `json:"reportingCurrency,omitempty"`
+	SystemReportingMinor int64             `json:"systemReportingAmountMinor,omitempty"`
+	BankReportingMinor   int64             `json:"bankReportingAmountMinor,omitempty"`
+	BankName             string            `json:"bank"`
+	Strategy             string            `json:"strategy"`
+	Reason               string            `json:"reason"`
+	CrossesPeriod        bool              `json:"crossesPeriodBoundary,omitempty"`
+	SystemSource         models.Source     `json:"systemSource"`
+	BankSource           models.Source     `json:"bankSource"`
+	SystemAttributes     map[string]string `json:"systemAttributes,omitempty"`
+	BankAttributes       map[string]string `json:"bankAttributes,omitempty"`
+}
+
+// DateDiff is an ID-matched pair whose system and bank dates are too far apart
+type DateDiff struct {
+	ID               string    `json:"id"`
+	UniqueIdentifier string    `json:"unique_identifier,omitempty"` // set when the bank ID differs from ID
+	BankName         string    `json:"bank"`
+	SystemTime       time.Time `json:"systemTransactionTime"`
+	SystemDate       time.Time `json:"systemBusinessDate"`
+	BankDate         time.Time `json:"bankDate"`
+	DayGap           int       `json:"dayGap"`
+	Strategy         string    `json:"strategy"`
+}
+
+// MatchedPair records every match with the strategy that produced it
+type MatchedPair struct {
+	TrxID            string  `json:"trxID"`
+	UniqueIdentifier string  `json:"unique_identifier"`
+	BankName         string  `json:"bank"`
+	Strategy         string  `json:"strategy"`
+	Confidence       float64 `json:"confidence"`
+	CrossesPeriod    bool    `json:"crossesPeriodBoundary,omitempty"`
+}
+
+// HeuristicMatch is a pair found by amount and date proximity only, without a shared ID
+type HeuristicMatch struct {
+	TrxID            string  `json:"trxID"`
+	UniqueIdentifier string  `json:"unique_identifier"`
+	BankName         string  `json:"bank"`
+	AmountMinor      int64   `json:"amountMinor"` // signed
+	Currency         string  `json:"currency"`
+	DayGap           int     `json:"dayGap"`
+	Confidence       float64 `json:"confidence"`
+	Strategy         string  `json:"strategy"`
+	CrossesPeriod    bool    `json:"crossesPeriodBoundary,omitempty"`
 }
 
 type Summary struct {
-	TotalProcessed           int                          `json:"totalProcessed"`
-	TotalMatched             int                          `json:"totalMatched"`
-	TotalUnmatched           int                          `json:"totalUnmatched"`
-	TotalAmountDiscrepancy   int64                        `json:"totalAmountDiscrepancyMinor"`
-	SystemMissingInBank      []UnmatchedSystem            `json:"systemMissingInBank"`
-	BankMissingInSystem      map[string][]UnmatchedBank   `json:"bankMissingInSystem"`
-	MatchedWithDiscrepancies []MatchedDiff                `json:"matchedWithDiscrepancies"`
-	Notes                    []string                     `json:"notes,omitempty"`
+	TotalProcessed          int   `json:"totalProcessed"`
+	TotalMatched            int   `json:"totalMatched"`
+	TotalMatchedByHeuristic int   `json:"totalMatchedByHeuristic"`
+	TotalMatchedGroups      int   `json:"totalMatchedGroups"`
+	TotalCrossPeriodMatches int   `json:"totalCrossPeriodMatches"`
+	TotalUnmatched          int   `json:"totalUnmatched"`
+	TotalAmountDiscrepancy  int64 `json:"totalAmountDiscrepancyMinor"`
+	// DiscrepancyCurrency is the currency of TotalAmountDiscrepancy: the only currency
+	// with discrepancies, or the reporting currency when several were converted with FX
+	DiscrepancyCurrency          string                     `json:"totalAmountDiscrepancyCurrency,omitempty"`
+	SystemMissingInBank          []UnmatchedSystem          `json:"systemMissingInBank"`
+	BankMissingInSystem          map[string][]UnmatchedBank `json:"bankMissingInSystem"`
+	MatchedWithDiscrepancies     []MatchedDiff              `json:"matchedWithDiscrepancies"`
+	DiscrepanciesByReason        map[string]int             `json:"discrepanciesByReason,omitempty"`
+	MatchedWithinTolerance       []MatchedDiff              `json:"matchedWithinTolerance,omitempty"`
+	MatchedWithFXDifferences     []MatchedDiff              `json:"matchedWithFXDifferences,omitempty"`
+	TotalFXDifference            int64                      `json:"totalFXDifferenceMinor"`
+	MatchedWithDateDiscrepancies []DateDiff                 `json:"matchedWithDateDiscrepancies,omitempty"`
+	MatchedByHeuristic           []HeuristicMatch           `json:"matchedByHeuristic,omitempty"`
+	MatchedGroups                []GroupMatch               `json:"matchedGroups,omitempty"`
+	MatchedByStrategy            map[string]int             `json:"matchedByStrategy"`
+	DuplicateSystemTransactions  []DuplicateSystem          `json:"duplicateSystemTransactions,omitempty"`
+	DuplicateBankEntries         []DuplicateBank            `json:"duplicateBankEntries,omitempty"`
+	Matches                      []MatchedPair              `json:"matches"`
+	TotalsByCurrency             map[string]CurrencyTotals  `json:"totalsByCurrency"`
+	TotalRejected                int                        `json:"totalRejectedRows"`
+	RejectedRows                 []parser.Reject            `json:"rejectedRows,omitempty"`
+	Notes                        []string                   `json:"notes,omitempty"`
+}
+
+// Options configures the matching engine.
+type Options struct {
+	// Matchers is the ordered strategy chain; nil means DefaultMatchers()
+	Matchers []Matcher
+	// Heuristic appends the amount+date matcher (AmountDateMatcher with DayWindow) to the chain
+	// unless it already holds one
+	Heuristic bool
+	// DayWindow is the max distance in days between system and bank dates for a heuristic match
+	DayWindow int
+	// Group enables split/batched settlement matching after the chain
+	Group GroupOptions
+	// Tolerance applies to every bank without an entry in BankTolerance
+	Tolerance     Tolerance
+	BankTolerance map[string]Tolerance
+	// Rules classify differences outside tolerance
+	Rules DiscrepancyRules
+	// SystemDuplicates resolves repeated trxIDs; empty means DuplicateLast
+	SystemDuplicates DuplicatePolicy
+	// MaxDateGapDays flags ID-matched pairs whose dates are further apart; nil disables the
+	// check and 0 requires the same day
+	MaxDateGapDays *int
+	// FX converts both amounts of a cross-currency pair into ReportingCurrency (default
+	// models.DefaultCurrency) at the bank date; differences within FXTolerance are FX differences
+	FX                *fx.Table
+	ReportingCurrency string
+	FXTolerance       Tolerance
+	// Rejects are rows skipped by lenient parsing; they are reported as-is
+	Rejects []parser.Reject
+	// ReportAttributes names the row attributes copied next to unmatched and discrepant items
+	// (case-insensitive; "*" copies all)
+	ReportAttributes []string
+	// Notes are input remarks (e.g. skipped duplicate files) copied to the summary
+	Notes []string
+}
+
+// matchers returns the chain to run, with the heuristic pass appended when requested
+func (o Options) matchers() []Matcher {
+	chain := o.Matchers
+	if chain == nil {
+		chain = DefaultMatchers()
+	}
+	if !o.Heuristic {
+		return chain
+	}
+	for _, m := range chain {
+		if m.Name() == StrategyAmountDate {
+			return chain
+		}
+	}
+	return append(append([]Matcher(nil), chain...), AmountDateMatcher{DayWindow: o.DayWindow})
+}
+
+func (o Options) toleranceFor(bank string) Tolerance {
+	if t, ok := o.BankTolerance[bank]; ok {
+		return t
+	}
+	return o.Tolerance
 }
 
+// Reconcile runs the default (ID-based) matcher chain
 func Reconcile(systemTxns []models.SystemTransaction, bankFiles []*parser.BankFile) Summary {
-	// system map by id
-	sysByID := map[string]models.SystemTransaction{}
+	return ReconcileWithOptions(systemTxns, bankFiles, Options{})
+}
+
+func ReconcileWithOptions(systemTxns []models.SystemTransaction, bankFiles []*parser.BankFile, opts Options) Summary {
+	// one system row per id, duplicates resolved by policy
+	sysRows, sysDups := dedupeSystem(systemTxns, opts.SystemDuplicates)
+	// one bank row per id, the surplus is reported as duplicate bank entries
+	bankRows, bankDups := dedupeBank(bankFiles, sysRows)
+
+	// Buffer rows (OutOfPeriod) are only counterparts and are not counted
+	byCurrency := currencyTotals{}
+	var totalProcessed int
 	for _, s := range systemTxns {
-		sysByID[s.TrxID] = s
-	}
-	// bank map by id (store first occurrence + bank name)
-	type bankEntry struct {
-		row models.BankStatement
+		if !s.OutOfPeriod {
+			totalProcessed++
+			byCurrency.get(s.Currency).TotalProcessed++
+		}
 	}
-	banked := map[string]bankEntry{}
-	// Track duplicates in bank ids for transparency
-	duplicateBankIDs := map[string][]string{} // id -> banks
 	for _, bf := range bankFiles {
 		for _, r := range bf.Rows {
-			if exist, ok := banked[r.UniqueIdentifier]; ok {
-				duplicateBankIDs[r.UniqueIdentifier] = appendUnique(duplicateBankIDs[r.UniqueIdentifier], exist.row.BankName, r.BankName)
-				// Keep the first one deterministically (existing)
-				continue
+			if !r.OutOfPeriod {
+				totalProcessed++
+				byCurrency.get(r.Currency).TotalProcessed++
 			}
-			banked[r.UniqueIdentifier] = bankEntry{row: r}
 		}
 	}
 
-	totalProcessed := len(systemTxns)
-	for _, bf := range bankFiles {
-		totalProcessed += len(bf.Rows)
-	}
+	// Deterministic input order for the matchers
+	sysLeft := sysRows
+	sort.Slice(sysLeft, func(i, j int) bool { return sysLeft[i].TrxID < sysLeft[j].TrxID })
+	bankLeft := bankRows
+	sort.Slice(bankLeft, func(i, j int) bool { return bankLeft[i].UniqueIdentifier < bankLeft[j].UniqueIdentifier })
+
+	matchers := opts.matchers()
 
 	var totalMatched int
-	var totalAmountDiscrepancy int64
-	var sysMissing []UnmatchedSystem
-	bankMissingGrouped := map[string][]UnmatchedBank{}
+	discrepancy := newDiscrepancyTotal()
 	var matchedDiffs []MatchedDiff
+	var withinTolerance []MatchedDiff
+	var fxDiffs []MatchedDiff
+	var totalFXDifference int64
+	var dateDiffs []DateDiff
+	byReason := map[string]int{}
+	var heuristic []HeuristicMatch
+	var matches []MatchedPair
+	byStrategy := map[string]int{}
+	var totalCrossPeriod int
 
-	// Matched and system-missing
-	for id, s := range sysByID {
-		if be, ok := banked[id]; ok {
-			totalMatched++
+	for _, m := range matchers {
+		if len(sysLeft) == 0 || len(bankLeft) == 0 {
+			break
+		}
+		sysUsed := make([]bool, len(sysLeft))
+		bankUsed := make([]bool, len(bankLeft))
+		for _, p := range m.Match(sysLeft, bankLeft) {
+			if p.SystemIndex < 0 || p.SystemIndex >= len(sysLeft) || p.BankIndex < 0 || p.BankIndex >= len(bankLeft) {
+				continue
+			}
+			if sysUsed[p.SystemIndex] || bankUsed[p.BankIndex] {
+				continue
+			}
+			sysUsed[p.SystemIndex] = true
+			bankUsed[p.BankIndex] = true
+			s, r := sysLeft[p.SystemIndex], bankLeft[p.BankIndex]
+			if s.OutOfPeriod && r.OutOfPeriod {
+				// both rows belong to another period
+				continue
+			}
+			crosses := s.OutOfPeriod || r.OutOfPeriod
+			if crosses {
+				totalCrossPeriod++
+			}
+			byStrategy[m.Name()]++
+			matches = append(matches, MatchedPair{
+				TrxID:            s.TrxID,
+				UniqueIdentifier: r.UniqueIdentifier,
+				BankName:         r.BankName,
+				Strategy:         m.Name(),
+				Confidence:       p.Confidence,
+				CrossesPeriod:    crosses,
+			})
 			sysSigned, _ := s.Type.SignedAmount(s.AmountMinor)
-			bankSigned := be.row.AmountMinor
-			diff := abs64(sysSigned - bankSigned)
-			if diff != 0 {
-				totalAmountDiscrepancy += abs64(diff)
-				matchedDiffs = append(matchedDiffs, MatchedDiff{
-					ID:                id,
-					SystemAmountMinor: sysSigned,
-					BankAmountMinor:   bankSigned,
-					AbsDiffMinor:      abs64(diff),
-					BankName:          be.row.BankName,
+			if p.Heuristic {
+				heuristic = append(heuristic, HeuristicMatch{
+					TrxID:            s.TrxID,
+					UniqueIdentifier: r.UniqueIdentifier,
+					BankName:         r.BankName,
+					AmountMinor:      r.AmountMinor,
+					Currency:         models.NormalizeCurrency(r.Currency),
+					DayGap:           dayGap(s.DateFor(r.BankName), r.Date),
+					Confidence:       p.Confidence,
+					Strategy:         m.Name(),
+					CrossesPeriod:    crosses,
 				})
+				continue
+			}
+			totalMatched++
+			byCurrency.get(s.Currency).TotalMatched++
+			if gap := dayGap(s.DateFor(r.BankName), r.Date); opts.MaxDateGapDays != nil && gap > *opts.MaxDateGapDays {
+				dd := DateDiff{
+					ID:         s.TrxID,
+					BankName:   r.BankName,
+					SystemTime: s.TransactionTime,
+					SystemDate: s.DateFor(r.BankName),
+					BankDate:   r.Date,
+					DayGap:     gap,
+					Strategy:   m.Name(),
+				}
+				if r.UniqueIdentifier != s.TrxID {
+					dd.UniqueIdentifier = r.UniqueIdentifier
+				}
+				dateDiffs = append(dateDiffs, dd)
+			}
+			md := MatchedDiff{
+				ID:                s.TrxID,
+				SystemAmountMinor: sysSigned,
+				BankAmountMinor:   r.AmountMinor,
+				SystemCurrency:    models.NormalizeCurrency(s.Currency),
+				BankCurrency:      models.NormalizeCurrency(r.Currency),
+				BankName:          r.BankName,
+				Strategy:          m.Name(),
+				CrossesPeriod:     crosses,
+				SystemSource:      s.Source,
+				BankSource:        r.Source,
+				SystemAttributes:  pickAttributes(s.Attributes, opts.ReportAttributes),
+				BankAttributes:    pickAttributes(r.Attributes, opts.ReportAttributes),
+			}
+			if r.UniqueIdentifier != s.TrxID {
+				md.UniqueIdentifier = r.UniqueIdentifier
+			}
+			sysCmp, bankCmp, cmpCurrency := sysSigned, r.AmountMinor, md.BankCurrency
+			viaFX := md.SystemCurrency != md.BankCurrency
+			if viaFX {
+				var err error
+				cmpCurrency = opts.reportingCurrency()
+				sysCmp, bankCmp, err = opts.toReporting(s, r)
+				if err != nil {
+					// amounts in different minor units cannot be compared
+					md.Reason = ReasonCurrencyMismatch
+					byReason[md.Reason]++
+					matchedDiffs = append(matchedDiffs, md)
+					continue
+				}
+				md.ReportingCurrency = cmpCurrency
+				md.SystemReportingMinor = sysCmp
+				md.BankReportingMinor = bankCmp
+			}
+			diff := abs64(sysCmp - bankCmp)
+			if diff != 0 {
+				md.AbsDiffMinor = diff
+				md.Reason = classifyDiff(sysCmp, bankCmp, opts.Rules)
+				// A sign flip is never accepted, whatever the tolerance
+				if viaFX && md.Reason != ReasonSignFlip && opts.FXTolerance.Within(diff, sysCmp) {
+					md.Reason = ReasonFX
+					totalFXDifference += diff
+					fxDiffs = append(fxDiffs, md)
+					continue
+				}
+				if !viaFX && md.Reason != ReasonSignFlip && opts.toleranceFor(r.BankName).Within(diff, sysSigned) {
+					withinTolerance = append(withinTolerance, md)
+					continue
+				}
+				discrepancy.add(diff, cmpCurrency, r.Date, opts)
+				byCurrency.get(cmpCurrency).TotalAmountDiscrepancy += diff
+				byReason[md.Reason]++
+				matchedDiffs = append(matchedDiffs, md)
 			}
-		} else {
-			sysMissing = append(sysMissing, UnmatchedSystem{
-				TrxID:       id,
-				AmountMinor: s.AmountMinor,
-				Type:        string(s.Type),
-			})
 		}
+		sysLeft = keepUnused(sysLeft, sysUsed)
+		bankLeft = keepUnused(bankLeft, bankUsed)
 	}
 
-	// Bank-missing
-	for id, be := range banked {
-		if _, ok := sysByID[id]; !ok {
-			bankMissingGrouped[be.row.BankName] = append(bankMissingGrouped[be.row.BankName], UnmatchedBank{
-				UniqueIdentifier: id,
-				AmountMinor:      be.row.AmountMinor,
-				BankName:         be.row.BankName,
-			})
+	var groups []GroupMatch
+	if opts.Group.Enabled {
+		groups, sysLeft, bankLeft = matchGroups(sysLeft, bankLeft, opts.Group)
+	}
+
+	for _, g := range groups {
+		if g.CrossesPeriod {
+			totalCrossPeriod++
 		}
 	}
 
+	var sysMissing []UnmatchedSystem
+	for _, s := range sysLeft {
+		if s.OutOfPeriod {
+			continue
+		}
+		byCurrency.get(s.Currency).TotalUnmatched++
+		sysMissing = append(sysMissing, UnmatchedSystem{
+			TrxID:       s.TrxID,
+			AmountMinor: s.AmountMinor,
+			Currency:    models.NormalizeCurrency(s.Currency),
+			Type:        string(s.Type),
+			Source:      s.Source,
+			Attributes:  pickAttributes(s.Attributes, opts.ReportAttributes),
+		})
+	}
+	bankMissingGrouped := map[string][]UnmatchedBank{}
+	for _, r := range bankLeft {
+		if r.OutOfPeriod {
+			continue
+		}
+		byCurrency.get(r.Currency).TotalUnmatched++
+		bankMissingGrouped[r.BankName] = append(bankMissingGrouped[r.BankName], UnmatchedBank{
+			UniqueIdentifier: r.UniqueIdentifier,
+			AmountMinor:      r.AmountMinor,
+			Currency:         models.NormalizeCurrency(r.Currency),
+			BankName:         r.BankName,
+			Source:           r.Source,
+			Attributes:       pickAttributes(r.Attributes, opts.ReportAttributes),
+		})
+	}
+
 	// Deterministic ordering
 	sort.Slice(sysMissing, func(i, j int) bool { return sysMissing[i].TrxID < sysMissing[j].TrxID })
 	for bank := range bankMissingGrouped {
@@ -122,26 +403,59 @@ func Reconcile(systemTxns []models.SystemTransaction, bankFiles []*parser.BankFi
 		})
 	}
 	sort.Slice(matchedDiffs, func(i, j int) bool { return matchedDiffs[i].ID < matchedDiffs[j].ID })
+	sort.Slice(withinTolerance, func(i, j int) bool { return withinTolerance[i].ID < withinTolerance[j].ID })
+	sort.Slice(fxDiffs, func(i, j int) bool { return fxDiffs[i].ID < fxDiffs[j].ID })
+	sort.Slice(dateDiffs, func(i, j int) bool { return dateDiffs[i].ID < dateDiffs[j].ID })
+	sort.Slice(heuristic, func(i, j int) bool { return heuristic[i].TrxID < heuristic[j].TrxID })
+	sort.Slice(matches, func(i, j int) bool { return matches[i].TrxID < matches[j].TrxID })
+	sort.Slice(groups, func(i, j int) bool { return groups[i].TrxIDs[0] < groups[j].TrxIDs[0] })
 
-	var notes []string
-	if len(duplicateBankIDs) > 0 {
-		notes = append(notes, formatDuplicateNotes(duplicateBankIDs))
-	}
+	rejects := append([]parser.Reject(nil), opts.Rejects...)
+	sort.SliceStable(rejects, func(i, j int) bool {
+		if rejects[i].File != rejects[j].File {
+			return rejects[i].File < rejects[j].File
+		}
+		return rejects[i].Line < rejects[j].Line
+	})
 
 	totalUnmatched := len(sysMissing)
 	for _, v := range bankMissingGrouped {
 		totalUnmatched += len(v)
 	}
 
+	notes := append([]string(nil), opts.Notes...)
+	totalAmountDiscrepancy, discrepancyCurrency, ok := discrepancy.result(opts)
+	if !ok {
+		notes = append(notes, "totalAmountDiscrepancyMinor is 0: discrepancies are in several currencies without FX rates to combine them, see totalsByCurrency")
+	}
+
 	return Summary{
-		TotalProcessed:           totalProcessed,
-		TotalMatched:             totalMatched,
-		TotalUnmatched:           totalUnmatched,
-		TotalAmountDiscrepancy:   totalAmountDiscrepancy,
-		SystemMissingInBank:      sysMissing,
-		BankMissingInSystem:      bankMissingGrouped,
-		MatchedWithDiscrepancies: matchedDiffs,
-		Notes:                    notes,
+		TotalProcessed:               totalProcessed,
+		TotalMatched:                 totalMatched,
+		TotalMatchedByHeuristic:      len(heuristic),
+		TotalMatchedGroups:           len(groups),
+		TotalCrossPeriodMatches:      totalCrossPeriod,
+		TotalUnmatched:               totalUnmatched,
+		TotalAmountDiscrepancy:       totalAmountDiscrepancy,
+		DiscrepancyCurrency:          discrepancyCurrency,
+		SystemMissingInBank:          sysMissing,
+		BankMissingInSystem:          bankMissingGrouped,
+		MatchedWithDiscrepancies:     matchedDiffs,
+		DiscrepanciesByReason:        byReason,
+		MatchedWithinTolerance:       withinTolerance,
+		MatchedWithFXDifferences:     fxDiffs,
+		TotalFXDifference:            totalFXDifference,
+		MatchedWithDateDiscrepancies: dateDiffs,
+		MatchedByHeuristic:           heuristic,
+		MatchedGroups:                groups,
+		MatchedByStrategy:            byStrategy,
+		DuplicateSystemTransactions:  sysDups,
+		DuplicateBankEntries:         bankDups,
+		Matches:                      matches,
+		TotalsByCurrency:             byCurrency.values(),
+		TotalRejected:                len(rejects),
+		RejectedRows:                 rejects,
+		Notes:                        notes,
 	}
 }
 
@@ -149,19 +463,86 @@ func HumanSummary(s Summary) string {
 	var b strings.Builder
 	fmt.Fprintf(&b, "Total processed: %d\n", s.TotalProcessed)
 	fmt.Fprintf(&b, "Total matched: %d\n", s.TotalMatched)
+	if s.TotalMatchedByHeuristic > 0 {
+		fmt.Fprintf(&b, "Total matched by heuristic: %d\n", s.TotalMatchedByHeuristic)
+	}
+	if s.TotalMatchedGroups > 0 {
+		fmt.Fprintf(&b, "Total matched groups: %d\n", s.TotalMatchedGroups)
+	}
+	if s.TotalCrossPeriodMatches > 0 {
+		fmt.Fprintf(&b, "Matches crossing the period boundary: %d\n", s.TotalCrossPeriodMatches)
+	}
 	fmt.Fprintf(&b, "Total unmatched: %d\n", s.TotalUnmatched)
-	fmt.Fprintf(&b, "Total amount discrepancy (minor): %d\n", s.TotalAmountDiscrepancy)
+	if s.DiscrepancyCurrency != "" {
+		fmt.Fprintf(&b, "Total amount discrepancy (minor): %d %s\n", s.TotalAmountDiscrepancy, s.DiscrepancyCurrency)
+	} else {
+		fmt.Fprintf(&b, "Total amount discrepancy (minor): %d\n", s.TotalAmountDiscrepancy)
+	}
+	if len(s.TotalsByCurrency) > 1 {
+		codes := make([]string, 0, len(s.TotalsByCurrency))
+		for c := range s.TotalsByCurrency {
+			codes = append(codes, c)
+		}
+		sort.Strings(codes)
+		for _, c := range codes {
+			t := s.TotalsByCurrency[c]
+			fmt.Fprintf(&b, "  [%s] processed=%d matched=%d unmatched=%d discrepancy(minor)=%d\n",
+				c, t.TotalProcessed, t.TotalMatched, t.TotalUnmatched, t.TotalAmountDiscrepancy)
+		}
+	}
+	if len(s.DiscrepanciesByReason) > 0 {
+		fmt.Fprintf(&b, "Discrepancies by reason: %s\n", formatCounts(s.DiscrepanciesByReason))
+	}
+	if len(s.MatchedByStrategy) > 0 {
+		fmt.Fprintf(&b, "Matched by strategy: %s\n", formatCounts(s.MatchedByStrategy))
+	}
 	if len(s.MatchedWithDiscrepancies) > 0 {
 		fmt.Fprintf(&b, "\nMatched with amount differences:\n")
 		for _, d := range s.MatchedWithDiscrepancies {
-			fmt.Fprintf(&b, "- %s (bank=%s): system=%d bank=%d diff=%d\n",
-				d.ID, d.BankName, d.SystemAmountMinor, d.BankAmountMinor, d.AbsDiffMinor)
+			fmt.Fprintf(&b, "- %s (bank=%s, strategy=%s): system=%d %s bank=%d %s diff=%d reason=%s%s\n",
+				d.ID, d.BankName, d.Strategy, d.SystemAmountMinor, d.SystemCurrency, d.BankAmountMinor, d.BankCurrency, d.AbsDiffMinor, d.Reason, periodMark(d.CrossesPeriod)+pairAttributesMark(d)+pairSourceMark(d))
+		}
+	}
+	if len(s.MatchedWithinTolerance) > 0 {
+		fmt.Fprintf(&b, "\nMatched within tolerance:\n")
+		for _, d := range s.MatchedWithinTolerance {
+			fmt.Fprintf(&b, "- %s (bank=%s): system=%d bank=%d diff=%d%s\n",
+				d.ID, d.BankName, d.SystemAmountMinor, d.BankAmountMinor, d.AbsDiffMinor, pairAttributesMark(d)+pairSourceMark(d))
+		}
+	}
+	if len(s.MatchedWithFXDifferences) > 0 {
+		fmt.Fprintf(&b, "\nMatched with FX differences (total %d minor):\n", s.TotalFXDifference)
+		for _, d := range s.MatchedWithFXDifferences {
+			fmt.Fprintf(&b, "- %s (bank=%s): system=%d %s bank=%d %s -> %s system=%d bank=%d diff=%d%s\n",
+				d.ID, d.BankName, d.SystemAmountMinor, d.SystemCurrency, d.BankAmountMinor, d.BankCurrency,
+				d.ReportingCurrency, d.SystemReportingMinor, d.BankReportingMinor, d.AbsDiffMinor, pairAttributesMark(d)+pairSourceMark(d))
+		}
+	}
+	if len(s.MatchedWithDateDiscrepancies) > 0 {
+		fmt.Fprintf(&b, "\nMatched with date differences:\n")
+		for _, d := range s.MatchedWithDateDiscrepancies {
+			fmt.Fprintf(&b, "- %s (bank=%s): system=%s bank=%s dayGap=%d\n",
+				d.ID, d.BankName, d.SystemDate.Format("2006-01-02"), d.BankDate.Format("2006-01-02"), d.DayGap)
+		}
+	}
+	if len(s.MatchedByHeuristic) > 0 {
+		fmt.Fprintf(&b, "\nMatched by heuristic (amount+date):\n")
+		for _, h := range s.MatchedByHeuristic {
+			fmt.Fprintf(&b, "- %s <-> %s (bank=%s): amount=%d dayGap=%d confidence=%.2f%s\n",
+				h.TrxID, h.UniqueIdentifier, h.BankName, h.AmountMinor, h.DayGap, h.Confidence, periodMark(h.CrossesPeriod))
+		}
+	}
+	if len(s.MatchedGroups) > 0 {
+		fmt.Fprintf(&b, "\nMatched groups (split/batched settlements):\n")
+		for _, g := range s.MatchedGroups {
+			fmt.Fprintf(&b, "- %s (bank=%s): system=[%s] bank=[%s] amount=%d%s\n",
+				g.Kind, g.BankName, strings.Join(g.TrxIDs, ","), strings.Join(g.BankIDs, ","), g.AmountMinor, periodMark(g.CrossesPeriod))
 		}
 	}
 	if len(s.SystemMissingInBank) > 0 {
 		fmt.Fprintf(&b, "\nSystem missing in bank:\n")
 		for _, u := range s.SystemMissingInBank {
-			fmt.Fprintf(&b, "- %s (%s) amountMinor=%d\n", u.TrxID, u.Type, u.AmountMinor)
+			fmt.Fprintf(&b, "- %s (%s) amountMinor=%d%s\n", u.TrxID, u.Type, u.AmountMinor, attributesMark(u.Attributes)+sourceMark(u.Source))
 		}
 	}
 	if len(s.BankMissingInSystem) > 0 {
@@ -175,10 +556,31 @@ func HumanSummary(s Summary) string {
 		for _, bank := range banks {
 			fmt.Fprintf(&b, "  [%s]\n", bank)
 			for _, u := range s.BankMissingInSystem[bank] {
-				fmt.Fprintf(&b, "  - %s amountMinor=%d\n", u.UniqueIdentifier, u.AmountMinor)
+				fmt.Fprintf(&b, "  - %s amountMinor=%d%s\n", u.UniqueIdentifier, u.AmountMinor, attributesMark(u.Attributes)+sourceMark(u.Source))
 			}
 		}
 	}
+	if len(s.DuplicateSystemTransactions) > 0 {
+		fmt.Fprintf(&b, "\nDuplicate system transactions:\n")
+		for _, d := range s.DuplicateSystemTransactions {
+			fmt.Fprintf(&b, "- %s (policy=%s):\n", d.TrxID, d.Policy)
+			for _, o := range d.Occurrences {
+				fmt.Fprintf(&b, "  - %s amountMinor=%d time=%s\n", o.Type, o.AmountMinor, o.TransactionTime.Format(time.RFC3339))
+			}
+		}
+	}
+	if len(s.DuplicateBankEntries) > 0 {
+		fmt.Fprintf(&b, "\nDuplicate bank entries:\n")
+		for _, d := range s.DuplicateBankEntries {
+			fmt.Fprintf(&b, "- %s (bank=%s) amountMinor=%d date=%s\n", d.UniqueIdentifier, d.BankName, d.AmountMinor, d.Date.Format("2006-01-02"))
+		}
+	}
+	if len(s.RejectedRows) > 0 {
+		fmt.Fprintf(&b, "\nRejected rows: %d\n", s.TotalRejected)
+		for _, r := range s.RejectedRows {
+			fmt.Fprintf(&b, "- %s:%d %s\n", r.File, r.Line, r.Reason)
+		}
+	}
 	if len(s.Notes) > 0 {
 		fmt.Fprintf(&b, "\nNotes:\n")
 		for _, n := range s.Notes {
@@ -188,6 +590,66 @@ func HumanSummary(s Summary) string {
 	return b.String()
 }
 
+// pickAttributes returns the requested attributes present in attrs, nil when none
+func pickAttributes(attrs map[string]string, names []string) map[string]string {
+	var out map[string]string
+	for _, n := range names {
+		for k, v := range attrs {
+			if n != "*" && !strings.EqualFold(k, n) {
+				continue
+			}
+			if out == nil {
+				out = map[string]string{}
+			}
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// attributesMark formats attributes as " {k=v, ...}" sorted by key
+func attributesMark(attrs map[string]string) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + attrs[k]
+	}
+	return " {" + strings.Join(parts, ", ") + "}"
+}
+
+func pairAttributesMark(d MatchedDiff) string {
+	var out string
+	if len(d.SystemAttributes) > 0 {
+		out += " system" + attributesMark(d.SystemAttributes)
+	}
+	if len(d.BankAttributes) > 0 {
+		out += " bank" + attributesMark(d.BankAttributes)
+	}
+	return out
+}
+
+// sourceMark appends " [path:line #hash]" when the row source is known
+func sourceMark(src models.Source) string {
+	if src.Path == "" {
+		return ""
+	}
+	return " [" + src.String() + "]"
+}
+
+func pairSourceMark(d MatchedDiff) string {
+	if d.SystemSource.Path == "" && d.BankSource.Path == "" {
+		return ""
+	}
+	return fmt.Sprintf(" [system %s; bank %s]", d.SystemSource, d.BankSource)
+}
+
 func abs64(v int64) int64 {
 	if v < 0 {
 		return -v
@@ -195,32 +657,33 @@ func abs64(v int64) int64 {
 	return v
 }
 
-func appendUnique(dst []string, vals ...string) []string {
-	set := map[string]struct{}{}
-	for _, d := range dst {
-		set[d] = struct{}{}
-	}
-	for _, v := range vals {
-		if _, ok := set[v]; !ok {
-			dst = append(dst, v)
-			set[v] = struct{}{}
-		}
+func periodMark(crosses bool) string {
+	if crosses {
+		return " [crosses period boundary]"
 	}
-	return dst
+	return ""
 }
 
-func formatDuplicateNotes(dups map[string][]string) string {
-	// Produce compact note string
-	keys := make([]string, 0, len(dups))
-	for k := range dups {
+// formatCounts renders "a=1 b=2" in key order
+func formatCounts(m map[string]int) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
 		keys = append(keys, k)
 	}
 	sort.Strings(keys)
-	var parts []string
-	for _, id := range keys {
-		parts = append(parts, fmt.Sprintf("%s in banks=%v", id, dups[id]))
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%d", k, m[k]))
 	}
-	return "duplicate bank IDs detected: " + strings.Join(parts, "; ")
+	return strings.Join(parts, " ")
 }
 
-
+func keepUnused[T any](rows []T, used []bool) []T {
+	var out []T
+	for i, r := range rows {
+		if !used[i] {
+			out = append(out, r)
+		}
+	}
+	return out
+}
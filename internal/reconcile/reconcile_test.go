@@ -1,10 +1,14 @@
 package reconcile_test
 
 import (
+	"math/big"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"recon-service/internal/fx"
 	"recon-service/internal/models"
 	"recon-service/internal/parser"
 	"recon-service/internal/reconcile"
@@ -164,8 +168,672 @@ func TestReconcile_FullScenario(t *testing.T) {
 	if len(sum.MatchedWithDiscrepancies) == 0 {
 		t.Fatalf("MatchedWithDiscrepancies should not be empty")
 	}
-	// Notes should contain duplicate id info (DUP-100)
-	if len(sum.Notes) == 0 {
-		t.Fatalf("Notes should not be empty (expect duplicates)")
+	// The second DUP-100 (bank_bni) is reported as a duplicate bank entry
+	if len(sum.DuplicateBankEntries) != 1 || sum.DuplicateBankEntries[0].UniqueIdentifier != "DUP-100" || sum.DuplicateBankEntries[0].BankName != "bank_bni" {
+		t.Fatalf("DuplicateBankEntries unexpected: %+v", sum.DuplicateBankEntries)
+	}
+	// Every reported row points back to its file and line
+	if src := sum.SystemMissingInBank[0].Source; src.Path != systemPath || src.Line != 5 || len(src.RecordHash) != 16 {
+		t.Fatalf("S4 source unexpected: %+v", src)
+	}
+	d := sum.MatchedWithDiscrepancies[0]
+	if d.SystemSource.Path != systemPath || d.SystemSource.Line != 4 || d.BankSource.Path != bcaPath || d.BankSource.Line != 3 {
+		t.Fatalf("S3 sources unexpected: system=%+v bank=%+v", d.SystemSource, d.BankSource)
+	}
+	if !strings.Contains(reconcile.HumanSummary(sum), systemPath+":5 #") {
+		t.Fatalf("human summary misses the S4 source")
+	}
+}
+
+func TestReconcile_HeuristicAmountAndDate(t *testing.T) {
+	sys := []models.SystemTransaction{
+		{TrxID: "S-1", AmountMinor: 5000, Type: models.TypeCredit, TransactionTime: mustDate("2024-03-01")},
+		{TrxID: "S-2", AmountMinor: 700, Type: models.TypeDebit, TransactionTime: mustDate("2024-03-01")},
+		{TrxID: "S-3", AmountMinor: 900, Type: models.TypeCredit, TransactionTime: mustDate("2024-03-01")},
+	}
+	banks := []*parser.BankFile{{
+		BankName: "bank_a",
+		Rows: []models.BankStatement{
+			{UniqueIdentifier: "REF-A", AmountMinor: 5000, Date: mustDate("2024-03-01"), BankName: "bank_a"},
+			{UniqueIdentifier: "REF-B", AmountMinor: -700, Date: mustDate("2024-03-03"), BankName: "bank_a"},
+			// outside the window
+			{UniqueIdentifier: "REF-C", AmountMinor: 900, Date: mustDate("2024-03-09"), BankName: "bank_a"},
+		},
+	}}
+
+	// disabled by default
+	sum := reconcile.Reconcile(sys, banks)
+	if sum.TotalMatchedByHeuristic != 0 || sum.TotalUnmatched != 6 {
+		t.Fatalf("default run got heuristic=%d unmatched=%d want 0/6", sum.TotalMatchedByHeuristic, sum.TotalUnmatched)
+	}
+
+	sum = reconcile.ReconcileWithOptions(sys, banks, reconcile.Options{Heuristic: true, DayWindow: 2})
+	if sum.TotalMatchedByHeuristic != 2 {
+		t.Fatalf("TotalMatchedByHeuristic got=%d want=%d", sum.TotalMatchedByHeuristic, 2)
+	}
+	if sum.TotalUnmatched != 2 {
+		t.Fatalf("TotalUnmatched got=%d want=%d", sum.TotalUnmatched, 2)
+	}
+	h := sum.MatchedByHeuristic
+	if h[0].TrxID != "S-1" || h[0].UniqueIdentifier != "REF-A" || h[0].Confidence != 1 {
+		t.Fatalf("unexpected first heuristic match: %+v", h[0])
+	}
+	if h[1].TrxID != "S-2" || h[1].UniqueIdentifier != "REF-B" || h[1].DayGap != 2 || h[1].Confidence >= 1 {
+		t.Fatalf("unexpected second heuristic match: %+v", h[1])
+	}
+}
+
+func TestReconcile_MatcherChainRecordsStrategy(t *testing.T) {
+	sys := []models.SystemTransaction{
+		{TrxID: "TX-001", AmountMinor: 100, Type: models.TypeCredit, TransactionTime: mustDate("2024-03-01")},
+		{TrxID: "tx-002", AmountMinor: 200, Type: models.TypeCredit, TransactionTime: mustDate("2024-03-01")},
+		{TrxID: "INV-77", AmountMinor: 300, Type: models.TypeDebit, TransactionTime: mustDate("2024-03-01")},
+		// embedded in a reference but with another amount
+		{TrxID: "INV-78", AmountMinor: 300, Type: models.TypeDebit, TransactionTime: mustDate("2024-03-01")},
+		// too short: would match the date segment of a reference
+		{TrxID: "0301", AmountMinor: 100, Type: models.TypeCredit, TransactionTime: mustDate("2024-03-01")},
+		{TrxID: "S-9", AmountMinor: 400, Type: models.TypeCredit, TransactionTime: mustDate("2024-03-01")},
+	}
+	banks := []*parser.BankFile{{
+		BankName: "bank_a",
+		Rows: []models.BankStatement{
+			{UniqueIdentifier: "TX-001", AmountMinor: 100, Date: mustDate("2024-03-01"), BankName: "bank_a"},
+			{UniqueIdentifier: "TX 002", AmountMinor: 200, Date: mustDate("2024-03-01"), BankName: "bank_a"},
+			{UniqueIdentifier: "TRF/INV-77/0301", AmountMinor: -300, Date: mustDate("2024-03-01"), BankName: "bank_a"},
+			{UniqueIdentifier: "TRF/INV-78/0301", AmountMinor: -310, Date: mustDate("2024-03-01"), BankName: "bank_a"},
+			{UniqueIdentifier: "TRF/INV-99/0301", AmountMinor: 500000, Date: mustDate("2024-03-01"), BankName: "bank_a"},
+			{UniqueIdentifier: "REF-X", AmountMinor: 400, Date: mustDate("2024-03-01"), BankName: "bank_a"},
+		},
+	}}
+
+	// the default chain is exact only
+	sum := reconcile.Reconcile(sys, banks)
+	if sum.TotalMatched != 1 || sum.Matches[0].TrxID != "TX-001" {
+		t.Fatalf("default chain got matches=%+v", sum.Matches)
+	}
+
+	chain, err := reconcile.MatchersByName([]string{"exact", "normalized", "reference"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum = reconcile.ReconcileWithOptions(sys, banks, reconcile.Options{Matchers: chain, Heuristic: true, DayWindow: 1})
+	if sum.TotalMatched != 3 || sum.TotalMatchedByHeuristic != 1 {
+		t.Fatalf("got matched=%d heuristic=%d want 3/1: %+v", sum.TotalMatched, sum.TotalMatchedByHeuristic, sum.Matches)
+	}
+	want := map[string]string{
+		"TX-001": reconcile.StrategyExactID,
+		"tx-002": reconcile.StrategyNormalized,
+		"INV-77": reconcile.StrategyReference,
+		"S-9":    reconcile.StrategyAmountDate,
+	}
+	for _, m := range sum.Matches {
+		if want[m.TrxID] != m.Strategy {
+			t.Fatalf("match %s strategy got=%s want=%s", m.TrxID, m.Strategy, want[m.TrxID])
+		}
+	}
+	if len(sum.MatchedWithDiscrepancies) != 0 {
+		t.Fatalf("unexpected discrepancies: %+v", sum.MatchedWithDiscrepancies)
+	}
+	if len(sum.SystemMissingInBank) != 2 || sum.SystemMissingInBank[0].TrxID != "0301" || sum.SystemMissingInBank[1].TrxID != "INV-78" {
+		t.Fatalf("short and amount-mismatched IDs must stay unmatched: %+v", sum.SystemMissingInBank)
+	}
+}
+
+func TestReconcile_GroupMatching(t *testing.T) {
+	sys := []models.SystemTransaction{
+		// batched: three credits settled as one bank line
+		{TrxID: "B-1", AmountMinor: 1000, Type: models.TypeCredit, TransactionTime: mustDate("2024-04-01")},
+		{TrxID: "B-2", AmountMinor: 2500, Type: models.TypeCredit, TransactionTime: mustDate("2024-04-01")},
+		{TrxID: "B-3", AmountMinor: 500, Type: models.TypeCredit, TransactionTime: mustDate("2024-04-02")},
+		// split: one payout across two bank lines
+		{TrxID: "P-1", AmountMinor: 9000, Type: models.TypeDebit, TransactionTime: mustDate("2024-04-03")},
+		{TrxID: "LONE", AmountMinor: 1, Type: models.TypeCredit, TransactionTime: mustDate("2024-04-03")},
+	}
+	banks := []*parser.BankFile{{
+		BankName: "bank_a",
+		Rows: []models.BankStatement{
+			{UniqueIdentifier: "SETTLE-01", AmountMinor: 4000, Date: mustDate("2024-04-02"), BankName: "bank_a"},
+			{UniqueIdentifier: "PAY-A", AmountMinor: -6000, Date: mustDate("2024-04-03"), BankName: "bank_a"},
+			{UniqueIdentifier: "PAY-B", AmountMinor: -3000, Date: mustDate("2024-04-04"), BankName: "bank_a"},
+		},
+	}}
+
+	sum := reconcile.ReconcileWithOptions(sys, banks, reconcile.Options{
+		Group: reconcile.GroupOptions{Enabled: true, DayWindow: 2},
+	})
+	if sum.TotalMatchedGroups != 2 {
+		t.Fatalf("TotalMatchedGroups got=%d want=%d: %+v", sum.TotalMatchedGroups, 2, sum.MatchedGroups)
+	}
+	if sum.TotalUnmatched != 1 || sum.SystemMissingInBank[0].TrxID != "LONE" {
+		t.Fatalf("expected only LONE unmatched, got %+v / %+v", sum.SystemMissingInBank, sum.BankMissingInSystem)
+	}
+	g := sum.MatchedGroups[0]
+	if g.Kind != reconcile.GroupManyToOne || len(g.TrxIDs) != 3 || g.BankIDs[0] != "SETTLE-01" {
+		t.Fatalf("unexpected batched group: %+v", g)
+	}
+	g = sum.MatchedGroups[1]
+	if g.Kind != reconcile.GroupOneToMany || g.TrxIDs[0] != "P-1" || len(g.BankIDs) != 2 || g.AmountMinor != -9000 {
+		t.Fatalf("unexpected split group: %+v", g)
+	}
+
+	// A tiny search budget finds nothing
+	sum = reconcile.ReconcileWithOptions(sys, banks, reconcile.Options{
+		Group: reconcile.GroupOptions{Enabled: true, DayWindow: 2, MaxSteps: 1},
+	})
+	if sum.TotalMatchedGroups != 0 {
+		t.Fatalf("TotalMatchedGroups with MaxSteps=1 got=%d want=%d", sum.TotalMatchedGroups, 0)
+	}
+}
+
+// The group search only visits rows inside DayWindow; scanning every row pair took minutes here
+func TestReconcile_GroupMatchingScales(t *testing.T) {
+	if testing.Short() {
+		t.Skip("large input")
+	}
+	const n = 20000
+	start := mustDate("2024-01-01")
+	var sys []models.SystemTransaction
+	var rows []models.BankStatement
+	for i := 0; i < n; i++ {
+		day := start.AddDate(0, 0, i%500)
+		sys = append(sys, models.SystemTransaction{TrxID: "S" + strconv.Itoa(i), AmountMinor: 1000, Type: models.TypeCredit, TransactionTime: day})
+		rows = append(rows, models.BankStatement{UniqueIdentifier: "B" + strconv.Itoa(i), AmountMinor: 1500, Date: day, BankName: "bank_a"})
+	}
+	// one batched settlement among the rows that cannot be grouped
+	sys = append(sys,
+		models.SystemTransaction{TrxID: "S-A", AmountMinor: 700, Type: models.TypeCredit, TransactionTime: start},
+		models.SystemTransaction{TrxID: "S-B", AmountMinor: 800, Type: models.TypeCredit, TransactionTime: start})
+
+	began := time.Now()
+	sum := reconcile.ReconcileWithOptions(sys, []*parser.BankFile{{BankName: "bank_a", Rows: rows}}, reconcile.Options{
+		Group: reconcile.GroupOptions{Enabled: true, DayWindow: 1},
+	})
+	if elapsed := time.Since(began); elapsed > 10*time.Second {
+		t.Fatalf("group matching of %d rows per side took %v", n, elapsed)
+	}
+	if sum.TotalMatchedGroups != 1 || len(sum.MatchedGroups[0].TrxIDs) != 2 || sum.MatchedGroups[0].TrxIDs[0] != "S-A" {
+		t.Fatalf("unexpected groups: %+v", sum.MatchedGroups)
+	}
+}
+
+func TestReconcile_ToleranceAndReasons(t *testing.T) {
+	sys := []models.SystemTransaction{
+		{TrxID: "R-1", AmountMinor: 10000, Type: models.TypeCredit, TransactionTime: mustDate("2024-05-01")},
+		{TrxID: "R-2", AmountMinor: 10000, Type: models.TypeCredit, TransactionTime: mustDate("2024-05-01")},
+		{TrxID: "R-3", AmountMinor: 10000, Type: models.TypeDebit, TransactionTime: mustDate("2024-05-01")},
+		{TrxID: "R-4", AmountMinor: 100000, Type: models.TypeCredit, TransactionTime: mustDate("2024-05-01")},
+		{TrxID: "R-5", AmountMinor: 100000, Type: models.TypeCredit, TransactionTime: mustDate("2024-05-01")},
+		{TrxID: "R-6", AmountMinor: 100000, Type: models.TypeCredit, TransactionTime: mustDate("2024-05-01")},
+	}
+	row := func(id string, amount int64, bank string) models.BankStatement {
+		return models.BankStatement{UniqueIdentifier: id, AmountMinor: amount, Date: mustDate("2024-05-01"), BankName: bank}
+	}
+	banks := []*parser.BankFile{
+		{BankName: "bank_a", Rows: []models.BankStatement{
+			row("R-1", 10050, "bank_a"), // within bank_a tolerance
+			row("R-3", 10000, "bank_a"), // sign flip, never tolerated
+		}},
+		{BankName: "bank_b", Rows: []models.BankStatement{
+			row("R-2", 9950, "bank_b"),   // rounding (bank_b has no tolerance)
+			row("R-4", 99000, "bank_b"),  // probable fee (1%)
+			row("R-5", 40000, "bank_b"),  // partial payment
+			row("R-6", 120000, "bank_b"), // overpayment
+		}},
+	}
+	sum := reconcile.ReconcileWithOptions(sys, banks, reconcile.Options{
+		BankTolerance: map[string]reconcile.Tolerance{"bank_a": {Percent: 1}},
+	})
+
+	if len(sum.MatchedWithinTolerance) != 1 || sum.MatchedWithinTolerance[0].ID != "R-1" {
+		t.Fatalf("MatchedWithinTolerance unexpected: %+v", sum.MatchedWithinTolerance)
+	}
+	want := map[string]string{
+		"R-2": reconcile.ReasonRounding,
+		"R-3": reconcile.ReasonSignFlip,
+		"R-4": reconcile.ReasonProbableFee,
+		"R-5": reconcile.ReasonPartialPayment,
+		"R-6": reconcile.ReasonOverpayment,
+	}
+	if len(sum.MatchedWithDiscrepancies) != len(want) {
+		t.Fatalf("MatchedWithDiscrepancies len got=%d want=%d", len(sum.MatchedWithDiscrepancies), len(want))
+	}
+	for _, d := range sum.MatchedWithDiscrepancies {
+		if d.Reason != want[d.ID] {
+			t.Fatalf("%s reason got=%s want=%s", d.ID, d.Reason, want[d.ID])
+		}
+	}
+	// 50 + 20000 + 1000 + 60000 + 20000
+	if sum.TotalAmountDiscrepancy != 101050 {
+		t.Fatalf("TotalAmountDiscrepancy got=%d want=%d", sum.TotalAmountDiscrepancy, 101050)
+	}
+
+	tol, err := reconcile.ParseTolerance("100,0.5%")
+	if err != nil || tol.AbsMinor != 100 || tol.Percent != 0.5 {
+		t.Fatalf("ParseTolerance got=%+v err=%v", tol, err)
+	}
+}
+
+func TestReconcile_SystemDuplicatePolicies(t *testing.T) {
+	sys := []models.SystemTransaction{
+		{TrxID: "D-1", AmountMinor: 1000, Type: models.TypeCredit, TransactionTime: mustDate("2024-06-01")},
+		{TrxID: "D-1", AmountMinor: 1500, Type: models.TypeCredit, TransactionTime: mustDate("2024-06-02")},
+		{TrxID: "U-1", AmountMinor: 700, Type: models.TypeCredit, TransactionTime: mustDate("2024-06-01")},
+	}
+	banks := []*parser.BankFile{{
+		BankName: "bank_a",
+		Rows: []models.BankStatement{
+			{UniqueIdentifier: "D-1", AmountMinor: 2500, Date: mustDate("2024-06-02"), BankName: "bank_a"},
+			{UniqueIdentifier: "U-1", AmountMinor: 700, Date: mustDate("2024-06-01"), BankName: "bank_a"},
+		},
+	}}
+
+	cases := []struct {
+		policy      reconcile.DuplicatePolicy
+		matched     int
+		discrepancy int64
+		unmatched   int
+	}{
+		{reconcile.DuplicateFirst, 2, 1500, 0},
+		{reconcile.DuplicateLast, 2, 1000, 0},
+		{reconcile.DuplicateSum, 2, 0, 0},
+		{reconcile.DuplicateReject, 1, 0, 1},
+	}
+	for _, c := range cases {
+		sum := reconcile.ReconcileWithOptions(sys, banks, reconcile.Options{SystemDuplicates: c.policy})
+		if sum.TotalMatched != c.matched || sum.TotalAmountDiscrepancy != c.discrepancy || sum.TotalUnmatched != c.unmatched {
+			t.Fatalf("policy=%s got matched=%d discrepancy=%d unmatched=%d want %d/%d/%d", c.policy,
+				sum.TotalMatched, sum.TotalAmountDiscrepancy, sum.TotalUnmatched, c.matched, c.discrepancy, c.unmatched)
+		}
+		if len(sum.DuplicateSystemTransactions) != 1 || len(sum.DuplicateSystemTransactions[0].Occurrences) != 2 {
+			t.Fatalf("policy=%s duplicates unexpected: %+v", c.policy, sum.DuplicateSystemTransactions)
+		}
+	}
+}
+
+func TestReconcile_DuplicateBankRowsBestFit(t *testing.T) {
+	sys := []models.SystemTransaction{
+		{TrxID: "X-1", AmountMinor: 5000, Type: models.TypeCredit, TransactionTime: mustDate("2024-07-02")},
+	}
+	banks := []*parser.BankFile{
+		{BankName: "bank_a", Rows: []models.BankStatement{
+			{UniqueIdentifier: "X-1", AmountMinor: 4000, Date: mustDate("2024-07-02"), BankName: "bank_a"},
+			// same bank, same id: exact amount but a day later
+			{UniqueIdentifier: "X-1", AmountMinor: 5000, Date: mustDate("2024-07-03"), BankName: "bank_a"},
+		}},
+		{BankName: "bank_b", Rows: []models.BankStatement{
+			// exact amount and same day: best fit
+			{UniqueIdentifier: "X-1", AmountMinor: 5000, Date: mustDate("2024-07-02"), BankName: "bank_b"},
+		}},
+	}
+
+	sum := reconcile.Reconcile(sys, banks)
+	if sum.TotalMatched != 1 || sum.TotalAmountDiscrepancy != 0 {
+		t.Fatalf("got matched=%d discrepancy=%d want 1/0", sum.TotalMatched, sum.TotalAmountDiscrepancy)
+	}
+	if sum.Matches[0].BankName != "bank_b" {
+		t.Fatalf("kept row from bank=%s want bank_b", sum.Matches[0].BankName)
+	}
+	if len(sum.DuplicateBankEntries) != 2 {
+		t.Fatalf("DuplicateBankEntries len got=%d want=%d", len(sum.DuplicateBankEntries), 2)
+	}
+	for _, d := range sum.DuplicateBankEntries {
+		if d.BankName != "bank_a" {
+			t.Fatalf("unexpected duplicate entry: %+v", d)
+		}
+	}
+}
+
+func TestReconcile_BlankBankIDsAreNotDuplicates(t *testing.T) {
+	sys := []models.SystemTransaction{
+		{TrxID: "S1", AmountMinor: 1000, Type: models.TypeCredit, TransactionTime: mustDate("2024-07-01")},
+		{TrxID: "S2", AmountMinor: 2000, Type: models.TypeCredit, TransactionTime: mustDate("2024-07-01")},
+	}
+	// an export with an empty reference column
+	banks := []*parser.BankFile{{BankName: "bank_a", Rows: []models.BankStatement{
+		{AmountMinor: 1000, Date: mustDate("2024-07-01"), BankName: "bank_a"},
+		{AmountMinor: 2000, Date: mustDate("2024-07-01"), BankName: "bank_a"},
+	}}}
+
+	sum := reconcile.ReconcileWithOptions(sys, banks, reconcile.Options{Heuristic: true, DayWindow: 1})
+	if len(sum.DuplicateBankEntries) != 0 {
+		t.Fatalf("blank IDs reported as duplicates: %+v", sum.DuplicateBankEntries)
+	}
+	if sum.TotalMatchedByHeuristic != 2 || sum.TotalUnmatched != 0 {
+		t.Fatalf("got heuristic=%d unmatched=%d want 2/0", sum.TotalMatchedByHeuristic, sum.TotalUnmatched)
+	}
+}
+
+func TestReconcile_DuplicateBankIDKeepsInPeriodRow(t *testing.T) {
+	banks := []*parser.BankFile{{BankName: "bank_a", Rows: []models.BankStatement{
+		// settlement buffer row from the previous period, then the in-period row
+		{UniqueIdentifier: "R-1", AmountMinor: 700, Date: mustDate("2023-12-31"), BankName: "bank_a", OutOfPeriod: true, Source: models.Source{Line: 2}},
+		{UniqueIdentifier: "R-1", AmountMinor: 700, Date: mustDate("2024-01-02"), BankName: "bank_a", Source: models.Source{Line: 3}},
+	}}}
+
+	sum := reconcile.Reconcile(nil, banks)
+	if sum.TotalProcessed != 1 || sum.TotalUnmatched != 1 || len(sum.DuplicateBankEntries) != 0 {
+		t.Fatalf("got processed=%d unmatched=%d duplicates=%+v want 1/1/none", sum.TotalProcessed, sum.TotalUnmatched, sum.DuplicateBankEntries)
+	}
+	if got := sum.BankMissingInSystem["bank_a"]; len(got) != 1 || got[0].Source.Line != 3 {
+		t.Fatalf("unexpected unmatched bank rows: %+v", got)
+	}
+}
+
+func TestReconcile_SettlementLagAcrossPeriodBoundary(t *testing.T) {
+	sysAll := []models.SystemTransaction{
+		// booked on the last day, settled next month
+		{TrxID: "END-1", AmountMinor: 100, Type: models.TypeCredit, TransactionTime: mustDate("2024-01-31")},
+		// booked last month, settled on the first day
+		{TrxID: "PREV-1", AmountMinor: 200, Type: models.TypeCredit, TransactionTime: mustDate("2023-12-31")},
+		// buffer row without an in-period counterpart: ignored
+		{TrxID: "PREV-2", AmountMinor: 300, Type: models.TypeCredit, TransactionTime: mustDate("2023-12-31")},
+		{TrxID: "MID-1", AmountMinor: 400, Type: models.TypeCredit, TransactionTime: mustDate("2024-01-15")},
+	}
+	bankAll := []*parser.BankFile{{
+		BankName: "bank_a",
+		Rows: []models.BankStatement{
+			{UniqueIdentifier: "END-1", AmountMinor: 100, Date: mustDate("2024-02-01"), BankName: "bank_a"},
+			{UniqueIdentifier: "PREV-1", AmountMinor: 200, Date: mustDate("2024-01-01"), BankName: "bank_a"},
+			{UniqueIdentifier: "MID-1", AmountMinor: 400, Date: mustDate("2024-01-15"), BankName: "bank_a"},
+			// both sides outside the period: ignored
+			{UniqueIdentifier: "NEXT-1", AmountMinor: 500, Date: mustDate("2024-02-02"), BankName: "bank_a"},
+		},
+	}}
+	start, end := mustDate("2024-01-01"), mustDate("2024-01-31")
+
+	// Without a buffer both boundary rows are unmatched
+	sum := reconcile.Reconcile(util.FilterSystemByDate(sysAll, start, end), util.FilterBanksByDate(bankAll, start, end))
+	if sum.TotalMatched != 1 || sum.TotalUnmatched != 2 {
+		t.Fatalf("no buffer got matched=%d unmatched=%d want 1/2", sum.TotalMatched, sum.TotalUnmatched)
+	}
+
+	buf := util.Buffer{LagDays: 3}
+	sum = reconcile.Reconcile(
+		util.FilterSystemByDateWithBuffer(sysAll, start, end, buf),
+		util.FilterBanksByDateWithBuffer(bankAll, start, end, buf),
+	)
+	if sum.TotalMatched != 3 || sum.TotalUnmatched != 0 {
+		t.Fatalf("with buffer got matched=%d unmatched=%d want 3/0", sum.TotalMatched, sum.TotalUnmatched)
+	}
+	// in-period rows only: END-1, MID-1 (system) + PREV-1, MID-1 (bank)
+	if sum.TotalProcessed != 4 {
+		t.Fatalf("TotalProcessed got=%d want=%d", sum.TotalProcessed, 4)
+	}
+	if sum.TotalCrossPeriodMatches != 2 {
+		t.Fatalf("TotalCrossPeriodMatches got=%d want=%d", sum.TotalCrossPeriodMatches, 2)
+	}
+	for _, m := range sum.Matches {
+		if m.CrossesPeriod != (m.TrxID != "MID-1") {
+			t.Fatalf("unexpected boundary flag: %+v", m)
+		}
+	}
+}
+
+func TestReconcile_DateDiscrepancies(t *testing.T) {
+	sys := []models.SystemTransaction{
+		{TrxID: "ON-TIME", AmountMinor: 100, Type: models.TypeCredit, TransactionTime: mustDate("2024-08-01")},
+		{TrxID: "STALE", AmountMinor: 200, Type: models.TypeCredit, TransactionTime: mustDate("2024-08-01")},
+	}
+	banks := []*parser.BankFile{{
+		BankName: "bank_a",
+		Rows: []models.BankStatement{
+			{UniqueIdentifier: "ON-TIME", AmountMinor: 100, Date: mustDate("2024-08-03"), BankName: "bank_a"},
+			{UniqueIdentifier: "STALE", AmountMinor: 200, Date: mustDate("2024-08-20"), BankName: "bank_a"},
+		},
+	}}
+
+	sum := reconcile.Reconcile(sys, banks)
+	if len(sum.MatchedWithDateDiscrepancies) != 0 {
+		t.Fatalf("date check should be off by default: %+v", sum.MatchedWithDateDiscrepancies)
+	}
+	maxGap := 3
+	sum = reconcile.ReconcileWithOptions(sys, banks, reconcile.Options{MaxDateGapDays: &maxGap})
+	if sum.TotalMatched != 2 {
+		t.Fatalf("TotalMatched got=%d want=%d", sum.TotalMatched, 2)
+	}
+	if len(sum.MatchedWithDateDiscrepancies) != 1 || sum.MatchedWithDateDiscrepancies[0].ID != "STALE" || sum.MatchedWithDateDiscrepancies[0].DayGap != 19 {
+		t.Fatalf("MatchedWithDateDiscrepancies unexpected: %+v", sum.MatchedWithDateDiscrepancies)
+	}
+	if !strings.Contains(reconcile.HumanSummary(sum), "Matched with date differences") {
+		t.Fatalf("human summary should list date differences")
+	}
+
+	// 0 is a valid maximum: dates must be the same day
+	maxGap = 0
+	sum = reconcile.ReconcileWithOptions(sys, banks, reconcile.Options{MaxDateGapDays: &maxGap})
+	if len(sum.MatchedWithDateDiscrepancies) != 2 {
+		t.Fatalf("same-day check got %+v", sum.MatchedWithDateDiscrepancies)
+	}
+}
+
+func TestReconcile_BusinessDatesWithTimezoneAndCutoff(t *testing.T) {
+	wib := time.FixedZone("WIB", 7*3600)
+	sys := []models.SystemTransaction{
+		// 23:30 WIB, written with two different offsets
+		{TrxID: "LATE-WIB", AmountMinor: 100, Type: models.TypeCredit, TransactionTime: time.Date(2024, 1, 31, 23, 30, 0, 0, wib)},
+		{TrxID: "LATE-UTC", AmountMinor: 200, Type: models.TypeCredit, TransactionTime: time.Date(2024, 1, 31, 16, 30, 0, 0, time.UTC)},
+		// 22:00 WIB stays on the 31st
+		{TrxID: "EARLY", AmountMinor: 300, Type: models.TypeCredit, TransactionTime: time.Date(2024, 1, 31, 15, 0, 0, 0, time.UTC)},
+	}
+	cal := util.Calendar{Location: wib, BankCutoff: map[string]time.Duration{"bank_a": 23 * time.Hour}}
+	sys = util.ApplyBusinessDates(sys, cal)
+
+	for _, s := range sys {
+		want := "2024-02-01"
+		if s.TrxID == "EARLY" {
+			want = "2024-01-31"
+		}
+		if got := s.DateFor("bank_a").Format("2006-01-02"); got != want {
+			t.Fatalf("%s bank_a business date got=%s want=%s", s.TrxID, got, want)
+		}
+		// no cut-off for other banks: plain WIB calendar date
+		if got := s.DateFor("bank_b").Format("2006-01-02"); got != "2024-01-31" {
+			t.Fatalf("%s default business date got=%s want=2024-01-31", s.TrxID, got)
+		}
+	}
+
+	banks := []*parser.BankFile{{
+		BankName: "bank_a",
+		Rows: []models.BankStatement{
+			{UniqueIdentifier: "LATE-WIB", AmountMinor: 100, Date: mustDate("2024-02-01"), BankName: "bank_a"},
+			{UniqueIdentifier: "LATE-UTC", AmountMinor: 200, Date: mustDate("2024-02-01"), BankName: "bank_a"},
+			{UniqueIdentifier: "EARLY", AmountMinor: 300, Date: mustDate("2024-01-31"), BankName: "bank_a"},
+		},
+	}}
+	sum := reconcile.ReconcileWithOptions(sys, banks, reconcile.Options{
+		Matchers: []reconcile.Matcher{reconcile.AmountDateMatcher{DayWindow: 0}},
+	})
+	if sum.TotalMatchedByHeuristic != 3 {
+		t.Fatalf("same-day heuristic matches got=%d want=%d", sum.TotalMatchedByHeuristic, 3)
+	}
+
+	// filtering uses the bank business dates: for bank_a the late rows belong to February
+	start, end := mustDate("2024-01-01"), mustDate("2024-01-31")
+	if got := util.FilterSystemByDateWithBuffer(sys, start, end, util.Buffer{}, "bank_a"); len(got) != 1 || got[0].TrxID != "EARLY" {
+		t.Fatalf("bank_a filter got %+v", got)
+	}
+	got := util.FilterSystemByDateWithBuffer(sys, start, end, util.Buffer{LeadDays: 1}, "bank_a")
+	if len(got) != 3 {
+		t.Fatalf("buffer filter got %+v", got)
+	}
+	for _, s := range got {
+		if s.OutOfPeriod != (s.TrxID != "EARLY") {
+			t.Fatalf("%s OutOfPeriod=%v", s.TrxID, s.OutOfPeriod)
+		}
+	}
+	// a bank without a cut-off still sees them on the 31st
+	if got := util.FilterSystemByDateWithBuffer(sys, start, end, util.Buffer{}, "bank_a", "bank_b"); len(got) != 3 || got[0].OutOfPeriod {
+		t.Fatalf("bank_a+bank_b filter got %+v", got)
+	}
+}
+
+func TestReconcile_CurrencyAware(t *testing.T) {
+	sys := []models.SystemTransaction{
+		{TrxID: "USD-1", AmountMinor: 1000, Currency: "USD", Type: models.TypeCredit, TransactionTime: mustDate("2024-09-01")},
+		{TrxID: "IDR-1", AmountMinor: 1000, Type: models.TypeCredit, TransactionTime: mustDate("2024-09-01")},
+		{TrxID: "SGD-1", AmountMinor: 500, Currency: "SGD", Type: models.TypeCredit, TransactionTime: mustDate("2024-09-01")},
+	}
+	banks := []*parser.BankFile{{
+		BankName: "bank_a",
+		Rows: []models.BankStatement{
+			{UniqueIdentifier: "USD-1", AmountMinor: 1000, Currency: "IDR", Date: mustDate("2024-09-01"), BankName: "bank_a"},
+			{UniqueIdentifier: "IDR-1", AmountMinor: 1100, Currency: "IDR", Date: mustDate("2024-09-01"), BankName: "bank_a"},
+			// same amount, other currency: no heuristic match
+			{UniqueIdentifier: "REF-X", AmountMinor: 500, Currency: "USD", Date: mustDate("2024-09-01"), BankName: "bank_a"},
+		},
+	}}
+	matchers := append(reconcile.DefaultMatchers(), reconcile.AmountDateMatcher{DayWindow: 1})
+	sum := reconcile.ReconcileWithOptions(sys, banks, reconcile.Options{Matchers: matchers})
+
+	if sum.TotalMatchedByHeuristic != 0 {
+		t.Fatalf("cross-currency heuristic match: %+v", sum.MatchedByHeuristic)
+	}
+	if sum.DiscrepanciesByReason[reconcile.ReasonCurrencyMismatch] != 1 {
+		t.Fatalf("expected one currency mismatch: %+v", sum.MatchedWithDiscrepancies)
+	}
+	if sum.TotalAmountDiscrepancy != 100 {
+		t.Fatalf("TotalAmountDiscrepancy got=%d want=%d", sum.TotalAmountDiscrepancy, 100)
+	}
+	idr, usd, sgd := sum.TotalsByCurrency["IDR"], sum.TotalsByCurrency["USD"], sum.TotalsByCurrency["SGD"]
+	if idr.TotalProcessed != 3 || idr.TotalMatched != 1 || idr.TotalAmountDiscrepancy != 100 {
+		t.Fatalf("IDR totals unexpected: %+v", idr)
+	}
+	if usd.TotalProcessed != 2 || usd.TotalMatched != 1 || usd.TotalUnmatched != 1 {
+		t.Fatalf("USD totals unexpected: %+v", usd)
+	}
+	if sgd.TotalUnmatched != 1 {
+		t.Fatalf("SGD totals unexpected: %+v", sgd)
+	}
+}
+
+func TestReconcile_MixedCurrencyDiscrepancyTotal(t *testing.T) {
+	sys := []models.SystemTransaction{
+		{TrxID: "USD-1", AmountMinor: 1000, Currency: "USD", Type: models.TypeCredit, TransactionTime: mustDate("2024-09-01")},
+		{TrxID: "IDR-1", AmountMinor: 100000, Type: models.TypeCredit, TransactionTime: mustDate("2024-09-01")},
+		// the USD row is the exact fit but the system row is in IDR
+		{TrxID: "DUP-1", AmountMinor: 5000, Type: models.TypeCredit, TransactionTime: mustDate("2024-09-01")},
+	}
+	banks := []*parser.BankFile{{
+		BankName: "bank_a",
+		Rows: []models.BankStatement{
+			{UniqueIdentifier: "USD-1", AmountMinor: 900, Currency: "USD", Date: mustDate("2024-09-01"), BankName: "bank_a"},
+			{UniqueIdentifier: "IDR-1", AmountMinor: 90000, Currency: "IDR", Date: mustDate("2024-09-01"), BankName: "bank_a"},
+			{UniqueIdentifier: "DUP-1", AmountMinor: 5000, Currency: "USD", Date: mustDate("2024-09-01"), BankName: "bank_a"},
+			{UniqueIdentifier: "DUP-1", AmountMinor: 4000, Currency: "IDR", Date: mustDate("2024-09-01"), BankName: "bank_a"},
+		},
+	}}
+
+	// 100 USD cents and 10000 IDR sen cannot be added
+	sum := reconcile.Reconcile(sys, banks)
+	if sum.TotalAmountDiscrepancy != 0 || sum.DiscrepancyCurrency != "" || len(sum.Notes) != 1 {
+		t.Fatalf("mixed total got=%d %s notes=%v", sum.TotalAmountDiscrepancy, sum.DiscrepancyCurrency, sum.Notes)
+	}
+	if sum.TotalsByCurrency["USD"].TotalAmountDiscrepancy != 100 || sum.TotalsByCurrency["IDR"].TotalAmountDiscrepancy != 11000 {
+		t.Fatalf("per-currency totals unexpected: %+v", sum.TotalsByCurrency)
+	}
+	// duplicates keep the row in the system currency
+	if len(sum.DuplicateBankEntries) != 1 || sum.DuplicateBankEntries[0].AmountMinor != 5000 {
+		t.Fatalf("unexpected duplicate entries: %+v", sum.DuplicateBankEntries)
+	}
+
+	// with rates the total is given in the reporting currency
+	rates := fx.NewTable()
+	rates.Add(mustDate("2024-08-30"), "USD", "IDR", big.NewRat(15000, 1))
+	sum = reconcile.ReconcileWithOptions(sys, banks, reconcile.Options{FX: rates})
+	if sum.TotalAmountDiscrepancy != 1500000+11000 || sum.DiscrepancyCurrency != "IDR" || len(sum.Notes) != 0 {
+		t.Fatalf("converted total got=%d %s notes=%v", sum.TotalAmountDiscrepancy, sum.DiscrepancyCurrency, sum.Notes)
+	}
+}
+
+func TestReconcile_FXConversion(t *testing.T) {
+	rates := fx.NewTable()
+	rates.Add(mustDate("2024-08-30"), "USD", "IDR", big.NewRat(15750, 1))
+	sys := []models.SystemTransaction{
+		{TrxID: "USD-1", AmountMinor: 1000, Currency: "USD", Type: models.TypeCredit, TransactionTime: mustDate("2024-09-01")},
+		{TrxID: "USD-2", AmountMinor: 1000, Currency: "USD", Type: models.TypeCredit, TransactionTime: mustDate("2024-09-01")},
+		{TrxID: "SGD-1", AmountMinor: 1000, Currency: "SGD", Type: models.TypeCredit, TransactionTime: mustDate("2024-09-01")},
+	}
+	banks := []*parser.BankFile{{
+		BankName: "bank_a",
+		Rows: []models.BankStatement{
+			// 10.00 USD = 157,500.00 IDR; the bank applied 158,000.00
+			{UniqueIdentifier: "USD-1", AmountMinor: 15800000, Currency: "IDR", Date: mustDate("2024-09-01"), BankName: "bank_a"},
+			{UniqueIdentifier: "USD-2", AmountMinor: 10000000, Currency: "IDR", Date: mustDate("2024-09-01"), BankName: "bank_a"},
+			// no SGD rate
+			{UniqueIdentifier: "SGD-1", AmountMinor: 11600000, Currency: "IDR", Date: mustDate("2024-09-01"), BankName: "bank_a"},
+		},
+	}}
+	sum := reconcile.ReconcileWithOptions(sys, banks, reconcile.Options{
+		FX:          rates,
+		FXTolerance: reconcile.Tolerance{Percent: 0.5},
+	})
+
+	if len(sum.MatchedWithFXDifferences) != 1 || sum.MatchedWithFXDifferences[0].ID != "USD-1" {
+		t.Fatalf("expected USD-1 as FX difference: %+v", sum.MatchedWithFXDifferences)
+	}
+	d := sum.MatchedWithFXDifferences[0]
+	if d.SystemReportingMinor != 15750000 || d.AbsDiffMinor != 50000 || d.ReportingCurrency != "IDR" || d.Reason != reconcile.ReasonFX {
+		t.Fatalf("unexpected FX difference: %+v", d)
+	}
+	if sum.TotalFXDifference != 50000 {
+		t.Fatalf("TotalFXDifference got=%d want=%d", sum.TotalFXDifference, 50000)
+	}
+	if sum.DiscrepanciesByReason[reconcile.ReasonPartialPayment] != 1 || sum.TotalAmountDiscrepancy != 5750000 {
+		t.Fatalf("expected USD-2 as partial payment of 5750000: %+v", sum.MatchedWithDiscrepancies)
+	}
+	if sum.DiscrepanciesByReason[reconcile.ReasonCurrencyMismatch] != 1 {
+		t.Fatalf("expected SGD-1 as currency mismatch: %+v", sum.MatchedWithDiscrepancies)
+	}
+}
+
+func TestReconcile_ReportsRejects(t *testing.T) {
+	rejects := []parser.Reject{
+		{File: "system.csv", Line: 7, Record: []string{"T7", "x"}, Reason: "trxID=T7 amount parse"},
+		{File: "bank.csv", Line: 3, Record: []string{"B3"}, Reason: "has 1 fields, header has 3"},
+		{File: "system.csv", Line: 2, Record: []string{"T2"}, Reason: "has 1 fields, header has 4"},
+	}
+	sum := reconcile.ReconcileWithOptions(nil, nil, reconcile.Options{Rejects: rejects})
+	if sum.TotalRejected != 3 {
+		t.Fatalf("TotalRejected got=%d want=3", sum.TotalRejected)
+	}
+	got := []string{}
+	for _, r := range sum.RejectedRows {
+		got = append(got, r.File+":"+strconv.Itoa(r.Line))
+	}
+	if strings.Join(got, ",") != "bank.csv:3,system.csv:2,system.csv:7" {
+		t.Fatalf("rejects not sorted by file and line: %v", got)
+	}
+	if !strings.Contains(reconcile.HumanSummary(sum), "Rejected rows: 3") {
+		t.Fatalf("human summary misses rejects")
+	}
+}
+
+func TestReconcile_ReportAttributes(t *testing.T) {
+	sys := []models.SystemTransaction{
+		{TrxID: "T1", AmountMinor: 1000, Type: models.TypeCredit, TransactionTime: mustDate("2024-01-01"), Attributes: map[string]string{"channel": "VA", "note": "x"}},
+		{TrxID: "T2", AmountMinor: 500, Type: models.TypeCredit, TransactionTime: mustDate("2024-01-01"), Attributes: map[string]string{"channel": "QRIS"}},
+	}
+	banks := []*parser.BankFile{{
+		BankName: "bank_a",
+		Rows: []models.BankStatement{
+			{UniqueIdentifier: "T1", AmountMinor: 900, Date: mustDate("2024-01-01"), BankName: "bank_a", Attributes: map[string]string{"Description": "fee deducted"}},
+		},
+	}}
+	sum := reconcile.ReconcileWithOptions(sys, banks, reconcile.Options{ReportAttributes: []string{"Channel", "description"}})
+
+	d := sum.MatchedWithDiscrepancies[0]
+	if len(d.SystemAttributes) != 1 || d.SystemAttributes["channel"] != "VA" || d.BankAttributes["Description"] != "fee deducted" {
+		t.Fatalf("unexpected diff attributes: %+v %+v", d.SystemAttributes, d.BankAttributes)
+	}
+	if got := sum.SystemMissingInBank[0].Attributes; got["channel"] != "QRIS" {
+		t.Fatalf("unexpected unmatched attributes: %v", got)
+	}
+	if !strings.Contains(reconcile.HumanSummary(sum), "- T2 (CREDIT) amountMinor=500 {channel=QRIS}") {
+		t.Fatalf("human summary misses attributes:\n%s", reconcile.HumanSummary(sum))
+	}
+
+	plain := reconcile.ReconcileWithOptions(sys, banks, reconcile.Options{})
+	if plain.SystemMissingInBank[0].Attributes != nil {
+		t.Fatalf("attributes reported without ReportAttributes")
 	}
 }
@@ -0,0 +1,97 @@
+package reconcile
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Note: Comments in English per instruction
+
+// Discrepancy reason codes
+const (
+	ReasonRounding       = "rounding"
+	ReasonSignFlip       = "sign_flip"
+	ReasonProbableFee    = "probable_fee"
+	ReasonPartialPayment = "partial_payment"
+	ReasonOverpayment    = "overpayment"
+	ReasonUnknown        = "unknown"
+)
+
+// Tolerance accepts an amount difference that is at most AbsMinor minor units
+// or at most Percent percent of the system amount. The zero value accepts nothing.
+type Tolerance struct {
+	AbsMinor int64   `json:"absMinor"`
+	Percent  float64 `json:"percent"`
+}
+
+// ParseTolerance reads "100", "0.5%" or both comma-separated ("100,0.5%").
+func ParseTolerance(spec string) (Tolerance, error) {
+	var t Tolerance
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if strings.HasSuffix(part, "%") {
+			v, err := strconv.ParseFloat(strings.TrimSuffix(part, "%"), 64)
+			if err != nil || v < 0 {
+				return Tolerance{}, fmt.Errorf("invalid tolerance percent: %s", part)
+			}
+			t.Percent = v
+			continue
+		}
+		v, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || v < 0 {
+			return Tolerance{}, fmt.Errorf("invalid tolerance minor units: %s", part)
+		}
+		t.AbsMinor = v
+	}
+	return t, nil
+}
+
+// Within reports whether diff (absolute) is acceptable for a system amount
+func (t Tolerance) Within(diff, sysAmount int64) bool {
+	if diff <= t.AbsMinor {
+		return true
+	}
+	return t.Percent > 0 && float64(diff)*100 <= t.Percent*float64(abs64(sysAmount))
+}
+
+// DiscrepancyRules tunes reason classification. Zero fields use the defaults below.
+type DiscrepancyRules struct {
+	// RoundingMaxMinor: differences up to this are rounding (default 99, i.e. below one major unit)
+	RoundingMaxMinor int64
+	// FeeMaxPercent: a bank shortfall up to this percent of the system amount is a probable fee (default 2)
+	FeeMaxPercent float64
+}
+
+func (r DiscrepancyRules) withDefaults() DiscrepancyRules {
+	if r.RoundingMaxMinor <= 0 {
+		r.RoundingMaxMinor = 99
+	}
+	if r.FeeMaxPercent <= 0 {
+		r.FeeMaxPercent = 2
+	}
+	return r
+}
+
+// classifyDiff returns the reason code for a non-zero difference between signed amounts
+func classifyDiff(sysSigned, bankSigned int64, rules DiscrepancyRules) string {
+	rules = rules.withDefaults()
+	diff := abs64(sysSigned - bankSigned)
+	switch {
+	case sysSigned != 0 && sysSigned == -bankSigned:
+		return ReasonSignFlip
+	case diff <= rules.RoundingMaxMinor:
+		return ReasonRounding
+	case (sysSigned < 0) != (bankSigned < 0) && bankSigned != 0:
+		return ReasonUnknown
+	case abs64(bankSigned) > abs64(sysSigned):
+		return ReasonOverpayment
+	case float64(diff)*100 <= rules.FeeMaxPercent*float64(abs64(sysSigned)):
+		return ReasonProbableFee
+	default:
+		return ReasonPartialPayment
+	}
+}
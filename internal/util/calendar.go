@@ -0,0 +1,59 @@
+package util
+
+import (
+	"fmt"
+	"time"
+
+	"recon-service/internal/models"
+)
+
+// Note: Comments in English per instruction
+
+// Calendar turns system timestamps into bank business dates: a time is converted
+// to Location and, at or after the cut-off time of day, counts for the next day.
+type Calendar struct {
+	Location   *time.Location           // nil keeps the zone each time was parsed with
+	Cutoff     time.Duration            // default cut-off as time of day; 0 means midnight
+	BankCutoff map[string]time.Duration // per-bank cut-off overriding Cutoff
+}
+
+// BusinessDate returns the business date (midnight UTC) of t for the given cut-off
+func (c Calendar) BusinessDate(t time.Time, cutoff time.Duration) time.Time {
+	if c.Location != nil {
+		t = t.In(c.Location)
+	}
+	d := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	tod := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if cutoff > 0 && tod >= cutoff {
+		d = d.AddDate(0, 0, 1)
+	}
+	return d
+}
+
+// ApplyBusinessDates sets BusinessDate (default cut-off) and BankBusinessDates
+// (per-bank cut-offs) on every row. Call it before filtering and matching.
+func ApplyBusinessDates(in []models.SystemTransaction, cal Calendar) []models.SystemTransaction {
+	out := make([]models.SystemTransaction, 0, len(in))
+	for _, x := range in {
+		x.BusinessDate = cal.BusinessDate(x.TransactionTime, cal.Cutoff)
+		if len(cal.BankCutoff) > 0 {
+			x.BankBusinessDates = make(map[string]time.Time, len(cal.BankCutoff))
+			for bank, cutoff := range cal.BankCutoff {
+				x.BankBusinessDates[bank] = cal.BusinessDate(x.TransactionTime, cutoff)
+			}
+		}
+		out = append(out, x)
+	}
+	return out
+}
+
+// ParseTimeOfDay reads "23:00" or "23:00:00" as a duration since midnight
+func ParseTimeOfDay(s string) (time.Duration, error) {
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid time of day: %q (want HH:MM)", s)
+}
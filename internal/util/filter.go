@@ -20,24 +20,79 @@ func betweenDays(t time.Time, start, end time.Time) bool {
 	return (td.Equal(sd) || td.After(sd)) && (td.Equal(ed) || td.Before(ed))
 }
 
+// Buffer widens the date range for counterpart rows around a period boundary.
+// LagDays covers banks settling after the system booking: system rows up to LagDays
+// before start and bank rows up to LagDays after end are kept. LeadDays is the reverse.
+type Buffer struct {
+	LagDays  int
+	LeadDays int
+}
+
 func FilterSystemByDate(in []models.SystemTransaction, start, end time.Time) []models.SystemTransaction {
+	return FilterSystemByDateWithBuffer(in, start, end, Buffer{})
+}
+
+// FilterSystemByDateWithBuffer keeps rows within the range and flags buffer rows as OutOfPeriod.
+// Rows are filtered on their business date for each of banks (see models.SystemTransaction.DateFor);
+// a row is kept when any of those dates is in the range and is in period when any is in period.
+// Without banks the default business date and every per-bank business date are used.
+func FilterSystemByDateWithBuffer(in []models.SystemTransaction, start, end time.Time, buf Buffer, banks ...string) []models.SystemTransaction {
+	from := start.AddDate(0, 0, -buf.LagDays)
+	to := end.AddDate(0, 0, buf.LeadDays)
 	out := make([]models.SystemTransaction, 0, len(in))
 	for _, x := range in {
-		if betweenDays(x.TransactionTime, start, end) {
-			out = append(out, x)
+		dates := businessDates(x, banks)
+		if !anyBetweenDays(dates, from, to) {
+			continue
 		}
+		x.OutOfPeriod = !anyBetweenDays(dates, start, end)
+		out = append(out, x)
 	}
 	return out
 }
 
+// businessDates lists the dates a row is compared on against the given banks
+func businessDates(x models.SystemTransaction, banks []string) []time.Time {
+	if len(banks) == 0 {
+		dates := []time.Time{x.DateFor("")}
+		for _, d := range x.BankBusinessDates {
+			dates = append(dates, d)
+		}
+		return dates
+	}
+	dates := make([]time.Time, 0, len(banks))
+	for _, b := range banks {
+		dates = append(dates, x.DateFor(b))
+	}
+	return dates
+}
+
+func anyBetweenDays(dates []time.Time, start, end time.Time) bool {
+	for _, d := range dates {
+		if betweenDays(d, start, end) {
+			return true
+		}
+	}
+	return false
+}
+
 func FilterBanksByDate(files []*parser.BankFile, start, end time.Time) []*parser.BankFile {
+	return FilterBanksByDateWithBuffer(files, start, end, Buffer{})
+}
+
+// FilterBanksByDateWithBuffer keeps rows within the range and flags buffer rows as OutOfPeriod
+func FilterBanksByDateWithBuffer(files []*parser.BankFile, start, end time.Time, buf Buffer) []*parser.BankFile {
+	from := start.AddDate(0, 0, -buf.LeadDays)
+	to := end.AddDate(0, 0, buf.LagDays)
 	var out []*parser.BankFile
 	for _, bf := range files {
 		var rows []models.BankStatement
 		for _, r := range bf.Rows {
-			if betweenDays(r.Date, start, end) {
-				rows = append(rows, r)
+			if !betweenDays(r.Date, from, to) {
+				continue
 			}
+			r.OutOfPeriod = !betweenDays(r.Date, start, end)
+			rows = append(rows, r)
 		}
 		out = append(out, &parser.BankFile{
 			BankName: bf.BankName,
@@ -46,5 +101,3 @@ func FilterBanksByDate(files []*parser.BankFile, start, end time.Time) []*parser
 	}
 	return out
 }
-
-